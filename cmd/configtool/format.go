@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	dotenvconfig "github.com/fengfenghuo/go-common-lib/config/dotenv"
+	iniconfig "github.com/fengfenghuo/go-common-lib/config/ini"
+	jsonconfig "github.com/fengfenghuo/go-common-lib/config/json"
+	tomlconfig "github.com/fengfenghuo/go-common-lib/config/toml"
+	yamlconfig "github.com/fengfenghuo/go-common-lib/config/yaml"
+)
+
+const mask = "******"
+
+// sensitiveKeys are the key names, lower-cased, whose values print masks
+// even when they are written in plain text.
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "credential", "private"}
+
+// dsnPassword matches the password of a DSN or URL, user:password@host, the
+// way log redaction masks it.
+var dsnPassword = regexp.MustCompile(`([\w.%-]+):[^@\s/:]+@`)
+
+// marshaler is implemented by the ConfigEngine of every adapter.
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// engines wrap raw data in the ConfigEngine of an adapter, so that it is
+// written the way the adapter writes its files.
+var engines = map[string]func(data map[string]interface{}) marshaler{
+	"json": func(data map[string]interface{}) marshaler {
+		return &jsonconfig.ConfigEngine{Data: data}
+	},
+	"yaml": func(data map[string]interface{}) marshaler {
+		return &yamlconfig.ConfigEngine{Data: data}
+	},
+	"toml": func(data map[string]interface{}) marshaler {
+		return &tomlconfig.ConfigEngine{Data: data}
+	},
+	"ini": func(data map[string]interface{}) marshaler {
+		return &iniconfig.ConfigEngine{Data: data}
+	},
+	"dotenv": func(data map[string]interface{}) marshaler {
+		env := make(map[string]interface{})
+		flattenEnv("", data, env)
+		return &dotenvconfig.ConfigEngine{Data: env}
+	},
+}
+
+// encode returns data in the format of adapter.
+func encode(adapter string, data map[string]interface{}) ([]byte, error) {
+	newEngine, ok := engines[adapter]
+	if !ok {
+		return nil, fmt.Errorf("unknown adapter %q", adapter)
+	}
+	return newEngine(data).Marshal()
+}
+
+// flattenEnv stores the leaves of v in env under SECTION_KEY names, lists of
+// scalars joined by ";" the way Strings splits them.
+func flattenEnv(name string, v interface{}, env map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, vv := range val {
+			flattenEnv(joinEnv(name, k), vv, env)
+		}
+		return
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, vv := range val {
+			if _, ok := vv.(map[string]interface{}); ok {
+				for i, vv := range val {
+					flattenEnv(joinEnv(name, fmt.Sprint(i)), vv, env)
+				}
+				return
+			}
+			parts[i] = fmt.Sprint(vv)
+		}
+		env[name] = strings.Join(parts, ";")
+		return
+	}
+	env[name] = v
+}
+
+func joinEnv(name, key string) string {
+	key = strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if name == "" {
+		return key
+	}
+	return name + "_" + key
+}
+
+// maskSecrets returns a copy of v in which secret placeholders, the values
+// of sensitive keys and the passwords of DSNs are masked. Placeholders are
+// resolved first, so that a secret the service could not read is reported.
+func maskSecrets(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, vv := range val {
+			if _, isMap := vv.(map[string]interface{}); !isMap && sensitive(k) {
+				res[k] = mask
+				continue
+			}
+			m, err := maskSecrets(vv)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = m
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, vv := range val {
+			m, err := maskSecrets(vv)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = m
+		}
+		return res, nil
+	case string:
+		if !config.IsSecret(val) {
+			return maskDSN(val), nil
+		}
+		if _, err := config.ResolveSecrets(val); err != nil {
+			return nil, err
+		}
+		return mask, nil
+	}
+	return v, nil
+}
+
+// maskDSN masks the password of every user:password@host in s.
+func maskDSN(s string) string {
+	return dsnPassword.ReplaceAllString(s, "${1}:"+mask+"@")
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten stores the leaves of v in leaves under their key paths, such as
+// db.module or servers[0].host, formatted so that the same value read by
+// different adapters compares equal.
+func flatten(path string, v interface{}, leaves map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && path != "" {
+			leaves[path] = "{}"
+		}
+		for k, vv := range val {
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			flatten(key, vv, leaves)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			leaves[path] = "[]"
+		}
+		for i, vv := range val {
+			flatten(fmt.Sprintf("%s[%d]", path, i), vv, leaves)
+		}
+	case float64:
+		// json numbers, 1000000 rather than 1e+06 as yaml and toml ints print
+		leaves[path] = strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		leaves[path] = fmt.Sprint(v)
+	}
+}
+
+// diffLeaves lists the keys removed from a (-), added in b (+) and changed
+// (~), sorted by key.
+func diffLeaves(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []string
+	for _, k := range keys {
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inB:
+			changes = append(changes, fmt.Sprintf("- %s: %s", k, display(k, va)))
+		case !inA:
+			changes = append(changes, fmt.Sprintf("+ %s: %s", k, display(k, vb)))
+		case va != vb:
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", k, display(k, va), display(k, vb)))
+		}
+	}
+	return changes
+}
+
+// display masks the value of a sensitive key path and DSN passwords.
+func display(path, v string) string {
+	name := path[strings.LastIndex(path, ".")+1:]
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	if sensitive(name) {
+		return mask
+	}
+	return maskDSN(v)
+}
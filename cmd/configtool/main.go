@@ -0,0 +1,238 @@
+// Command configtool shows config files the way services see them through
+// the config package.
+//
+// Usage:
+//
+//	configtool print [-adapter name] [-env prefix] [-format name] file
+//	configtool convert [-from name] -to name in out
+//	configtool diff a b
+//	configtool validate -schema schema.yaml [-env prefix] file
+//
+// print writes the effective config, with includes composed and environment
+// overrides applied, masking secrets. diff compares two files key by key,
+// whatever their formats. The adapter of a file is chosen by its extension
+// unless given explicitly.
+//
+// The exit status is 0 on success, 1 if diff finds differences or validate
+// finds violations, and 2 on errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+var commands = map[string]func(args []string, stdout, stderr io.Writer) (int, error){
+	"print":    printCmd,
+	"convert":  convertCmd,
+	"diff":     diffCmd,
+	"validate": validateCmd,
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || commands[args[0]] == nil {
+		fmt.Fprintln(stderr, "usage: configtool print|convert|diff|validate [flags] file...")
+		return 2
+	}
+	code, err := commands[args[0]](args[1:], stdout, stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "configtool %s: %s\n", args[0], err.Error())
+		}
+		return 2
+	}
+	return code
+}
+
+func printCmd(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("print", stderr)
+	adapter := fs.String("adapter", "", "adapter of the file, by default chosen by its extension")
+	env := fs.String("env", "", "apply environment overrides with this prefix")
+	format := fs.String("format", "", "output format, by default the adapter of the file")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return 0, err
+	}
+
+	file := fs.Arg(0)
+	conf, name, err := load(*adapter, file, envOptions(fs, *env)...)
+	if err != nil {
+		return 0, err
+	}
+	data, err := maskSecrets(rawData(conf))
+	if err != nil {
+		return 0, err
+	}
+	if *format == "" {
+		*format = name
+	}
+	b, err := encode(*format, data.(map[string]interface{}))
+	if err != nil {
+		return 0, err
+	}
+	_, err = stdout.Write(b)
+	return 0, err
+}
+
+func convertCmd(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("convert", stderr)
+	from := fs.String("from", "", "adapter of the input file, by default chosen by its extension")
+	to := fs.String("to", "", "adapter of the output file, by default chosen by its extension")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return 0, err
+	}
+
+	conf, _, err := load(*from, fs.Arg(0))
+	if err != nil {
+		return 0, err
+	}
+	if *to == "" {
+		if *to, err = adapterFor(fs.Arg(1)); err != nil {
+			return 0, err
+		}
+	}
+	// secrets are written as their placeholders, never resolved
+	b, err := encode(*to, rawData(conf))
+	if err != nil {
+		return 0, err
+	}
+	return 0, config.WriteFile(fs.Arg(1), b)
+}
+
+func diffCmd(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("diff", stderr)
+	if err := parseArgs(fs, args, 2); err != nil {
+		return 0, err
+	}
+
+	var leaves [2]map[string]string
+	for i := range leaves {
+		conf, _, err := load("", fs.Arg(i))
+		if err != nil {
+			return 0, err
+		}
+		leaves[i] = make(map[string]string)
+		flatten("", rawData(conf), leaves[i])
+	}
+
+	changes := diffLeaves(leaves[0], leaves[1])
+	for _, c := range changes {
+		fmt.Fprintln(stdout, c)
+	}
+	if len(changes) > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func validateCmd(args []string, stdout, stderr io.Writer) (int, error) {
+	fs := newFlagSet("validate", stderr)
+	schemaFile := fs.String("schema", "", "schema file, in any supported format")
+	env := fs.String("env", "", "apply environment overrides with this prefix")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return 0, err
+	}
+	if *schemaFile == "" {
+		return 0, fmt.Errorf("-schema is required")
+	}
+
+	sc, _, err := load("", *schemaFile)
+	if err != nil {
+		return 0, err
+	}
+	var schema config.Schema
+	if err := sc.Unmarshal("", &schema); err != nil {
+		return 0, fmt.Errorf("schema %s: %s", *schemaFile, err.Error())
+	}
+
+	conf, _, err := load("", fs.Arg(0), envOptions(fs, *env)...)
+	if err != nil {
+		return 0, err
+	}
+	err = schema.Validate(conf)
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		for _, v := range verr.Violations {
+			fmt.Fprintln(stdout, v)
+		}
+		return 1, nil
+	}
+	return 0, err
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("configtool "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseArgs parses the flags of fs and checks that n files are left.
+func parseArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != n {
+		return fmt.Errorf("expected %d file argument(s), got %d", n, fs.NArg())
+	}
+	return nil
+}
+
+// envOptions returns the WithEnv option if -env was given, even empty.
+func envOptions(fs *flag.FlagSet, prefix string) []config.Option {
+	var opts []config.Option
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "env" {
+			opts = append(opts, config.WithEnv(prefix))
+		}
+	})
+	return opts
+}
+
+var extAdapters = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".ini":  "ini",
+	".env":  "dotenv",
+}
+
+func adapterFor(file string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	if name, ok := extAdapters[ext]; ok {
+		return name, nil
+	}
+	if filepath.Base(file) == ".env" {
+		return "dotenv", nil
+	}
+	return "", fmt.Errorf("%s: unknown file extension, use -adapter", file)
+}
+
+// load opens file with the config package and returns the adapter used.
+func load(adapter, file string, opts ...config.Option) (config.Configer, string, error) {
+	if adapter == "" {
+		var err error
+		if adapter, err = adapterFor(file); err != nil {
+			return nil, "", err
+		}
+	}
+	conf, err := config.NewConfig(adapter, file, opts...)
+	return conf, adapter, err
+}
+
+func rawData(conf config.Configer) map[string]interface{} {
+	if dc, ok := conf.(config.DataContainer); ok {
+		return dc.RawData()
+	}
+	return map[string]interface{}{}
+}
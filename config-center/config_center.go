@@ -8,13 +8,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
+	"github.com/fengfenghuo/go-common-lib/log"
 	"github.com/fengfenghuo/go-common-lib/rpc"
 )
 
@@ -38,6 +41,7 @@ type CenterClient struct {
 	mqttClient       MQTT.Client
 	reconnectedTimer *time.Ticker
 	messageArrived   MQTT.MessageHandler
+	log              *logger.Logger
 }
 
 // NewInstance is the init function
@@ -51,8 +55,8 @@ func NewInstance(serverURL, mqttURL string) *CenterClient {
 	}
 
 	client.messageArrived = func(mqttClient MQTT.Client, msg MQTT.Message) {
-		log.Printf("接收消息tipic: %s", msg.Topic())
-		log.Printf("接收消息content: %s", msg.Payload())
+		client.getLogger().Infof("接收消息tipic: %s", msg.Topic())
+		client.getLogger().Infof("接收消息content: %s", msg.Payload())
 
 		type MessageData struct {
 			Content json.RawMessage `json:"content"`
@@ -61,7 +65,7 @@ func NewInstance(serverURL, mqttURL string) *CenterClient {
 		var message MessageData
 		err := json.Unmarshal(msg.Payload(), &message)
 		if err != nil {
-			log.Println("messageArrived Unmarshal msg error: " + err.Error())
+			client.getLogger().CusError(err, "messageArrived Unmarshal msg error: ")
 			return
 		}
 
@@ -82,13 +86,46 @@ func NewInstance(serverURL, mqttURL string) *CenterClient {
 	return &client
 }
 
+// stderrLog is the logger of the clients without one in a process that has
+// created no logger, so that their errors still reach stderr.
+var stderrLog = logger.NewWithCore(zapcore.NewCore(
+	zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()),
+	zapcore.Lock(os.Stderr),
+	zapcore.InfoLevel,
+), logger.LoggerConfig{ModuleName: "config-center", Level: "info"})
+
+// SetLogger sets the logger of client. Without one client logs to the first
+// logger created by the process, or to stderr if there is none.
+func (client *CenterClient) SetLogger(l *logger.Logger) {
+	client.log = l
+}
+
+func (client *CenterClient) getLogger() *logger.Logger {
+	if client.log != nil {
+		return client.log
+	}
+	if l, ok := logger.Default(); ok {
+		return l
+	}
+	return stderrLog
+}
+
+// RedirectMQTTLogs sends the ERROR, CRITICAL and WARN logs of the paho MQTT
+// client to l. They are globals of paho, shared by every client of the
+// process.
+func RedirectMQTTLogs(l *logger.Logger) {
+	MQTT.ERROR = l.StdLogger(zap.ErrorLevel)
+	MQTT.CRITICAL = l.StdLogger(zap.ErrorLevel)
+	MQTT.WARN = l.StdLogger(zap.WarnLevel)
+}
+
 func (client *CenterClient) SubscribeAndQuery(topicName string, monitor func(string, json.RawMessage) int) json.RawMessage {
 	topic, ok := client.topics[topicName]
 	if !ok {
 		var err error
 		topic, err = client.createTopic(topicName)
 		if err != nil {
-			log.Printf("center:SubscribeAndQuery:createTopic error: " + err.Error())
+			client.getLogger().CusError(err, "center:SubscribeAndQuery:createTopic error: ")
 			return nil
 		}
 	}
@@ -117,7 +154,7 @@ func (client *CenterClient) Unsubscribe(topicName string, monitor func(string, j
 
 func (client *CenterClient) updateTopicContent(topic *Topic, content json.RawMessage, version int) {
 	if version > topic.Version {
-		log.Printf("startSyncTimer: response: %s", string(content[:]))
+		client.getLogger().Infof("startSyncTimer: response: %s", string(content[:]))
 
 		isSuccess := true
 		for _, monitor := range topic.Monitors {
@@ -141,7 +178,7 @@ func (client *CenterClient) createTopic(topicName string) (*Topic, error) {
 	if err != nil {
 		return nil, fmt.Errorf("SendHttpRequest error: %s", err.Error())
 	}
-	log.Println("query topic data: ", string(res[:]))
+	client.getLogger().Infof("query topic data: %s", string(res[:]))
 	var topic Topic
 	err = json.Unmarshal(res, &topic)
 	if err != nil {
@@ -154,7 +191,7 @@ func (client *CenterClient) createTopic(topicName string) (*Topic, error) {
 
 func (client *CenterClient) subscribeTopic(topic *Topic) error {
 	if client.mqttClient == nil {
-		log.Println("mqtt-connect: " + client.mqttURL + " clientID: " + client.mqttClientID)
+		client.getLogger().Info("mqtt-connect: " + client.mqttURL + " clientID: " + client.mqttClientID)
 		client.buildConnectMqttClient()
 	}
 
@@ -189,7 +226,7 @@ func (client *CenterClient) doUnsubscribe(topic *Topic, monitor func(string, jso
 		}
 
 		delete(client.topics, topic.Name)
-		log.Printf("取消订阅: " + topic.Name)
+		client.getLogger().Info("取消订阅: " + topic.Name)
 	}
 	return nil
 }
@@ -210,7 +247,7 @@ func (client *CenterClient) startSyncTimer(delay time.Duration) {
 
 			err := client.startReConnect()
 			if err != nil {
-				log.Println(err)
+				client.getLogger().CusError(err, "startSyncTimer: ")
 				return
 			}
 		}
@@ -233,7 +270,7 @@ func (client *CenterClient) startSyncTimer(delay time.Duration) {
 
 		req, err := json.Marshal(topicArray)
 		if err != nil {
-			log.Printf("startSyncTimer: Marshal error: %s", err.Error())
+			client.getLogger().CusError(err, "startSyncTimer: Marshal error: ")
 			return
 		}
 
@@ -246,7 +283,7 @@ func (client *CenterClient) startSyncTimer(delay time.Duration) {
 
 		res, err := rpc.SendHttpRequest(url)
 		if err != nil {
-			log.Printf("startSyncTimer: SendHttpRequest error: %s", err.Error())
+			client.getLogger().CusError(err, "startSyncTimer: SendHttpRequest error: ")
 			return
 		}
 
@@ -259,7 +296,7 @@ func (client *CenterClient) startSyncTimer(delay time.Duration) {
 		var topics []MessageData
 		err = json.Unmarshal(res, &topics)
 		if err != nil {
-			log.Printf("startSyncTimer: Unmarshal %s, error: %s", string(res[:]), err.Error())
+			client.getLogger().Errorf("startSyncTimer: Unmarshal %s, error: %s", string(res[:]), err.Error())
 			return
 		}
 
@@ -288,7 +325,7 @@ func (client *CenterClient) checkStartReconnectTimer(delay time.Duration) error
 				select {
 				case _ = <-client.reconnectedTimer.C:
 					if err := client.startReConnect(); err != nil {
-						log.Println(err)
+						client.getLogger().CusError(err, "checkStartReconnectTimer: ")
 						continue
 					}
 					return
@@ -317,7 +354,7 @@ func (client *CenterClient) checkAndSubscribeAll() error {
 	for _, topic := range client.subscriptions {
 		if !topic.Subscribed {
 			if err := client.doSubscribeMqttTopic(topic.Name); err != nil {
-				log.Printf("checkAndSubscribeAll: doSubscribeMqttTopic : %s error: %s", topic.Name, err.Error())
+				client.getLogger().Errorf("checkAndSubscribeAll: doSubscribeMqttTopic : %s error: %s", topic.Name, err.Error())
 				continue
 			}
 			topic.Subscribed = true
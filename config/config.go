@@ -2,30 +2,49 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Configer defines how to get and set value from configuration raw data.
+// Keys are resolved the same way by every adapter, see Lookup: db::module
+// and db.module address the same value, servers[0].host a list element.
 type Configer interface {
-	String(key string) string    //support section::key type in key string when using ini and json type; Int,Int64,Bool,Float,DIY are same.
+	String(key string) string    //support section::key and section.key type in key string; Int,Int64,Bool,Float,DIY are same.
 	Strings(key string) []string //get string slice
 	Int(key string) (int, error)
 	Int64(key string) (int64, error)
 	Bool(key string) (bool, error)
 	Float(key string) (float64, error)
-	DefaultString(key string, defaultVal string) string      // support section::key type in key string when using ini and json type; Int,Int64,Bool,Float,DIY are same.
+	DefaultString(key string, defaultVal string) string      // support section::key and section.key type in key string; Int,Int64,Bool,Float,DIY are same.
 	DefaultStrings(key string, defaultVal []string) []string //get string slice
 	DefaultInt(key string, defaultVal int) int
 	DefaultInt64(key string, defaultVal int64) int64
 	DefaultBool(key string, defaultVal bool) bool
 	DefaultFloat(key string, defaultVal float64) float64
-	// SaveConfigFile(filename string) error
+	Uint64(key string) (uint64, error)
+	Duration(key string) (time.Duration, error) // "1m30s", see ParseDuration
+	Bytes(key string) (int64, error)            // "16MB", see ParseBytes
+	Time(key string) (time.Time, error)         // see ParseTime
+	StringMap(key string) (map[string]string, error)
+	IntSlice(key string) ([]int, error)
+	Float64Slice(key string) ([]float64, error)
+	Sub(key string) Configer                     // the section at key, nil if key is not a section
+	Unmarshal(key string, out interface{}) error // decode the section at key ("" for the whole file) into a struct, see Decode
+	Set(key string, value interface{}) error     // set key, creating missing sections
+	Delete(key string) error
+	SaveConfigFile(filename string) error // write the config atomically, see WriteFile
 }
 
 // Config is the adapter interface for parsing config file to get raw data to Configer.
 type Config interface {
 	Parse(key string) (Configer, error)
+	ParseData(data []byte) (Configer, error) // parse config content not read from a file
 }
 
 var adapters = make(map[string]Config)
@@ -43,21 +62,165 @@ func Register(name string, adapter Config) {
 	adapters[name] = adapter
 }
 
-// NewConfig adapterName is ini/json/xml/yaml.
-// filename is the config file path.
-func NewConfig(adapterName, filePath string) (Configer, error) {
+// DataContainer is implemented by Configers that keep the parsed config as a
+// map, so NewConfig options can work on the raw values.
+type DataContainer interface {
+	RawData() map[string]interface{}
+}
+
+// Option changes how NewConfig loads a config.
+type Option func(*options)
+
+type options struct {
+	env          bool
+	envPrefix    string
+	pollInterval time.Duration
+	schema       *Schema
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithEnv overlays environment variables on top of the parsed config. The
+// key db::module (json) or db.module (yaml) is overridden by PREFIX_DB_MODULE,
+// or DB_MODULE if prefix is empty. Only keys present in the file are
+// overridden and values are converted to the type found in the file. A config
+// with overridden keys cannot be saved, see Overlayer.
+func WithEnv(prefix string) Option {
+	return func(o *options) {
+		o.env = true
+		o.envPrefix = prefix
+	}
+}
+
+// NewConfig adapterName is ini/json/yaml/toml/dotenv.
+// filePath is the config file path, relative to the working directory unless
+// it is absolute. A secret placeholder that cannot be resolved is an error,
+// see CheckSecrets.
+func NewConfig(adapterName, filePath string, opts ...Option) (Configer, error) {
+	path, err := configPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return load(adapterName, path, newOptions(opts))
+}
+
+// NewConfigFromBytes parses the config content data with the adapter
+// adapterName. Included files are relative to the working directory.
+func NewConfigFromBytes(adapterName string, data []byte, opts ...Option) (Configer, error) {
+	adapter, err := getAdapter(adapterName)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := adapter.ParseData(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := composeData(conf, adapter, nil, ""); err != nil {
+		return nil, err
+	}
+	return finish(adapterName, conf, newOptions(opts))
+}
+
+// NewConfigFromReader parses the config content read from r, see
+// NewConfigFromBytes.
+func NewConfigFromReader(adapterName string, r io.Reader, opts ...Option) (Configer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfigFromBytes(adapterName, data, opts...)
+}
+
+// NewConfigFromFS parses the config file at path in fsys, such as an
+// embed.FS. Included files are read from fsys too.
+func NewConfigFromFS(adapterName string, fsys fs.FS, path string, opts ...Option) (Configer, error) {
+	adapter, err := getAdapter(adapterName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := adapter.ParseData(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := composeData(conf, adapter, fsys, path); err != nil {
+		return nil, err
+	}
+	return finish(adapterName, conf, newOptions(opts))
+}
+
+func configPath(filePath string) (string, error) {
+	if filepath.IsAbs(filePath) {
+		return filepath.Clean(filePath), nil
+	}
+	workPath, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("config: resolve %s: %s", filePath, err.Error())
+	}
+	return filepath.Join(workPath, filePath), nil
+}
+
+func getAdapter(adapterName string) (Config, error) {
 	adapter, ok := adapters[adapterName]
 	if !ok {
 		return nil, fmt.Errorf("config: unknown adaptername %q (forgotten import?)", adapterName)
 	}
+	return adapter, nil
+}
 
-	workPath, err := os.Getwd()
+func load(adapterName, path string, o options) (Configer, error) {
+	adapter, err := getAdapter(adapterName)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := adapter.Parse(path)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if err := composeData(conf, adapter, nil, path); err != nil {
+		return nil, err
+	}
+	return finish(adapterName, conf, o)
+}
+
+// finish applies the options to the parsed config conf.
+func finish(adapterName string, conf Configer, o options) (Configer, error) {
+	if o.env {
+		ov, ok := conf.(Overlayer)
+		if !ok {
+			return nil, fmt.Errorf("config: adapter %q does not support environment overlay", adapterName)
+		}
+		vars, err := overlayEnv(ov.RawData(), o.envPrefix)
+		if err != nil {
+			return nil, err
+		}
+		if len(vars) > 0 {
+			ov.SetOverlay(vars)
+		}
 	}
 
-	appConfigPath := filepath.Join(workPath, filePath)
-	return adapter.Parse(appConfigPath)
+	if dc, ok := conf.(DataContainer); ok {
+		if err := CheckSecrets(dc.RawData()); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.schema != nil {
+		if err := o.schema.Validate(conf); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
 }
 
 // ParseBool ...
@@ -73,7 +236,7 @@ func ParseBool(val interface{}) (value bool, err error) {
 			case "0", "f", "F", "false", "FALSE", "False", "NO", "no", "No", "N", "n", "OFF", "off", "Off":
 				return false, nil
 			}
-		case int8, int32, int64:
+		case int, int8, int32, int64:
 			strV := fmt.Sprintf("%d", v)
 			if strV == "1" {
 				return true, nil
@@ -91,3 +254,41 @@ func ParseBool(val interface{}) (value bool, err error) {
 	}
 	return false, fmt.Errorf("parsing <nil>: invalid syntax")
 }
+
+// ParseInt64 converts a raw config value to an int64. Strings are parsed
+// with strconv and floats must not have a fractional part.
+func ParseInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(v), 0, 64)
+	}
+	return 0, fmt.Errorf("not int value")
+}
+
+// ParseFloat converts a raw config value to a float64.
+func ParseFloat(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("not float64 value")
+}
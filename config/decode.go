@@ -0,0 +1,338 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Decode copies the raw config value in (as produced by the json or yaml
+// adapters) into the value pointed to by out.
+//
+// Struct fields are matched by the `config` tag, then the `json` and `yaml`
+// tags, then the field name (case-insensitive). A `default:"..."` tag is used
+// when the key is missing. Keys without a field are ignored, see
+// DecodeStrict. time.Duration fields accept strings such as "5s", see
+// ParseDuration, and time.Time fields the formats of ParseTime.
+func Decode(in interface{}, out interface{}) error {
+	return decode(in, out, false)
+}
+
+// DecodeStrict is Decode, but returns an *UnknownKeysError listing the keys
+// of in that match no struct field, such as a misspelled maxConn, after
+// decoding all others.
+func DecodeStrict(in interface{}, out interface{}) error {
+	return decode(in, out, true)
+}
+
+// UnmarshalStrict decodes the section at key of conf, or the whole config if
+// key is empty, into out with DecodeStrict.
+func UnmarshalStrict(conf Configer, key string, out interface{}) error {
+	var v interface{}
+	if err := conf.Unmarshal(key, &v); err != nil {
+		return err
+	}
+	return DecodeStrict(v, out)
+}
+
+// UnknownKeysError lists the keys found by DecodeStrict that match no struct
+// field.
+type UnknownKeysError struct {
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return "config: unknown keys " + strings.Join(e.Keys, ", ")
+}
+
+type decoder struct {
+	strict  bool
+	unknown []string
+}
+
+func decode(in interface{}, out interface{}, strict bool) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: Decode(non-pointer %T)", out)
+	}
+	d := decoder{strict: strict}
+	if err := d.decodeValue("", in, rv.Elem()); err != nil {
+		return err
+	}
+	if len(d.unknown) > 0 {
+		sort.Strings(d.unknown)
+		return &UnknownKeysError{Keys: d.unknown}
+	}
+	return nil
+}
+
+func (d *decoder) decodeValue(path string, in interface{}, v reflect.Value) error {
+	if in == nil {
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := ParseDuration(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.Type() == timeType {
+		t, err := ParseTime(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeValue(path, in, v.Elem())
+	case reflect.Interface:
+		rin := reflect.ValueOf(in)
+		if !rin.Type().AssignableTo(v.Type()) {
+			return decodeError(path, in, v, nil)
+		}
+		v.Set(rin)
+	case reflect.Struct:
+		m, ok := toStringMap(in)
+		if !ok {
+			return decodeError(path, in, v, nil)
+		}
+		used := make(map[string]bool, len(m))
+		if err := d.decodeStruct(path, m, v, used); err != nil {
+			return err
+		}
+		if d.strict {
+			for k := range m {
+				if !used[k] {
+					d.unknown = append(d.unknown, joinPath(path, k))
+				}
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return decodeError(path, in, v, fmt.Errorf("map key must be string"))
+		}
+		m, ok := toStringMap(in)
+		if !ok {
+			return decodeError(path, in, v, nil)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for key, val := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decodeValue(joinPath(path, key), val, elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := toList(in)
+		if !ok {
+			return decodeError(path, in, v, nil)
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(list), len(list)))
+		} else if len(list) > v.Len() {
+			return decodeError(path, in, v, fmt.Errorf("too many elements"))
+		}
+		for i, val := range list {
+			if err := d.decodeValue(fmt.Sprintf("%s[%d]", path, i), val, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		s, ok := toString(in)
+		if !ok {
+			return decodeError(path, in, v, nil)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := ParseBool(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := ParseInt64(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		if v.OverflowInt(i) {
+			return decodeError(path, in, v, fmt.Errorf("value out of range"))
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := ParseInt64(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return decodeError(path, in, v, fmt.Errorf("value out of range"))
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := ParseFloat(in)
+		if err != nil {
+			return decodeError(path, in, v, err)
+		}
+		if v.OverflowFloat(f) {
+			return decodeError(path, in, v, fmt.Errorf("value out of range"))
+		}
+		v.SetFloat(f)
+	default:
+		return decodeError(path, in, v, fmt.Errorf("unsupported type"))
+	}
+	return nil
+}
+
+// decodeStruct decodes m into the fields of v, recording the keys of m it
+// used in used.
+func (d *decoder) decodeStruct(path string, m map[string]interface{}, v reflect.Value, used map[string]bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Struct {
+				if err := d.decodeStruct(path, m, fv, used); err != nil {
+					return err
+				}
+				continue
+			}
+			name = field.Name
+		}
+
+		fieldPath := joinPath(path, name)
+		key, found := lookupKeyFold(m, name)
+		val := m[key]
+		if found {
+			used[key] = true
+		} else {
+			if def, ok := field.Tag.Lookup("default"); ok {
+				val = def
+			} else if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				// apply the defaults of nested structs as well
+				val = map[string]interface{}{}
+			}
+		}
+		if err := d.decodeValue(fieldPath, val, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName returns the config key of a struct field; ok is false if the
+// field is explicitly skipped with "-". An untagged embedded struct yields "".
+func fieldName(field reflect.StructField) (name string, ok bool) {
+	for _, tag := range []string{"config", "json", "yaml"} {
+		if v, found := field.Tag.Lookup(tag); found {
+			name = strings.Split(v, ",")[0]
+			if name == "-" {
+				return "", false
+			}
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	if field.Anonymous {
+		return "", true
+	}
+	return field.Name, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func decodeError(path string, in interface{}, v reflect.Value, err error) error {
+	if path == "" {
+		path = "<root>"
+	}
+	if err != nil {
+		return fmt.Errorf("config: cannot decode %T into %s at %q: %s", in, v.Type(), path, err.Error())
+	}
+	return fmt.Errorf("config: cannot decode %T into %s at %q", in, v.Type(), path)
+}
+
+// toStringMap converts the map types produced by the json and yaml parsers.
+func toStringMap(in interface{}) (map[string]interface{}, bool) {
+	switch m := in.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[fmt.Sprint(k)] = v
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// toList accepts a real list or, like Strings, a ";" separated string.
+func toList(in interface{}) ([]interface{}, bool) {
+	switch l := in.(type) {
+	case []interface{}:
+		return l, true
+	case string:
+		if l == "" {
+			return []interface{}{}, true
+		}
+		parts := strings.Split(l, ";")
+		res := make([]interface{}, len(parts))
+		for i, p := range parts {
+			res[i] = p
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+func toString(in interface{}) (string, bool) {
+	switch v := in.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,144 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+type serverConfig struct {
+	Host string `config:"host" default:"localhost"`
+	Port int    `config:"port" default:"8080"`
+}
+
+type appConfig struct {
+	Name     string                 `json:"name"`
+	Debug    bool                   `yaml:"debug"`
+	Interval time.Duration          `config:"interval"`
+	Ratio    float32                `config:"ratio" default:"0.5"`
+	Tags     []string               `config:"tags"`
+	Servers  []serverConfig         `config:"servers"`
+	Limits   map[string]int         `config:"limits"`
+	Primary  serverConfig           `config:"primary"`
+	Backup   *serverConfig          `config:"backup"`
+	Extra    map[string]interface{} `config:"extra"`
+	Ignored  string                 `config:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	in := map[string]interface{}{
+		"Name":     "demo",
+		"debug":    "on",
+		"interval": "1m30s",
+		"tags":     "a;b;c",
+		"servers": []interface{}{
+			map[interface{}]interface{}{"host": "10.0.0.1", "port": 9000},
+			map[string]interface{}{"port": float64(9001)},
+		},
+		"limits":  map[interface{}]interface{}{"conn": 10, "qps": float64(200)},
+		"backup":  map[string]interface{}{"host": "10.0.0.9"},
+		"extra":   map[string]interface{}{"k": "v"},
+		"Ignored": "x",
+	}
+
+	var conf appConfig
+	if err := config.Decode(in, &conf); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if conf.Name != "demo" || !conf.Debug || conf.Interval != 90*time.Second || conf.Ratio != 0.5 {
+		t.Errorf("unexpected scalars: %+v", conf)
+	}
+	if len(conf.Tags) != 3 || conf.Tags[2] != "c" {
+		t.Errorf("unexpected tags: %v", conf.Tags)
+	}
+	if len(conf.Servers) != 2 || conf.Servers[0].Port != 9000 || conf.Servers[1].Host != "localhost" || conf.Servers[1].Port != 9001 {
+		t.Errorf("unexpected servers: %+v", conf.Servers)
+	}
+	if conf.Limits["conn"] != 10 || conf.Limits["qps"] != 200 {
+		t.Errorf("unexpected limits: %v", conf.Limits)
+	}
+	if conf.Primary.Host != "localhost" || conf.Primary.Port != 8080 {
+		t.Errorf("nested defaults not applied: %+v", conf.Primary)
+	}
+	if conf.Backup == nil || conf.Backup.Host != "10.0.0.9" || conf.Backup.Port != 8080 {
+		t.Errorf("unexpected backup: %+v", conf.Backup)
+	}
+	if conf.Extra["k"] != "v" || conf.Ignored != "" {
+		t.Errorf("unexpected extra/ignored: %v %q", conf.Extra, conf.Ignored)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var conf appConfig
+	if err := config.Decode(map[string]interface{}{}, conf); err == nil {
+		t.Errorf("expected error for non-pointer")
+	}
+	if err := config.Decode(map[string]interface{}{"servers": []interface{}{map[string]interface{}{"port": 1.5}}}, &conf); err == nil {
+		t.Errorf("expected error for fractional int")
+	}
+	if err := config.Decode(map[string]interface{}{"interval": "soon"}, &conf); err == nil {
+		t.Errorf("expected error for bad duration")
+	}
+	if err := config.Decode(map[string]interface{}{"primary": "x"}, &conf); err == nil {
+		t.Errorf("expected error for scalar into struct")
+	}
+	if err := config.Decode(map[string]interface{}{"interval": 30}, &conf); err == nil {
+		t.Errorf("expected error for duration without unit")
+	}
+}
+
+func TestDecodeMissingTime(t *testing.T) {
+	var job struct {
+		Name  string    `config:"name"`
+		Start time.Time `config:"start"`
+		End   time.Time `config:"end" default:"2024-01-02"`
+	}
+	if err := config.Decode(map[string]interface{}{"name": "backup"}, &job); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if job.Name != "backup" || !job.Start.IsZero() || job.End.Format("2006-01-02") != "2024-01-02" {
+		t.Errorf("job = %+v", job)
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	in := map[string]interface{}{
+		"name":    "demo",
+		"hosst":   "x",
+		"servers": []interface{}{map[string]interface{}{"host": "a", "prot": 1}},
+		"backup":  map[interface{}]interface{}{"port": 1, "weight": 2},
+		"limits":  map[string]interface{}{"any": 1},
+		"extra":   map[string]interface{}{"any": "v"},
+	}
+
+	var conf appConfig
+	if err := config.Decode(in, &conf); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	conf = appConfig{}
+	err := config.DecodeStrict(in, &conf)
+	unknown, ok := err.(*config.UnknownKeysError)
+	if !ok {
+		t.Fatalf("DecodeStrict error = %v, want UnknownKeysError", err)
+	}
+	want := []string{"backup.weight", "hosst", "servers[0].prot"}
+	if !reflect.DeepEqual(unknown.Keys, want) {
+		t.Errorf("unknown keys = %v, want %v", unknown.Keys, want)
+	}
+	if conf.Name != "demo" || conf.Servers[0].Host != "a" || conf.Backup.Port != 1 {
+		t.Errorf("known keys not decoded: %+v", conf)
+	}
+
+	type Server serverConfig
+	type embedded struct {
+		Server
+		Weight int
+	}
+	var e embedded
+	if err := config.DecodeStrict(map[string]interface{}{"host": "a", "weight": 2}, &e); err != nil || e.Host != "a" || e.Port != 8080 {
+		t.Errorf("DecodeStrict into embedded struct = %+v, %v", e, err)
+	}
+}
@@ -0,0 +1,409 @@
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	"github.com/joho/godotenv"
+)
+
+func init() {
+	config.Register("dotenv", &Config{})
+}
+
+// Config is a .env config parser and implements Config interface.
+type Config struct{}
+
+// Parse returns a ConfigContainer with parsed .env config map.
+func (conf *Config) Parse(filename string) (config.Configer, error) {
+	env, err := godotenv.Read(filename)
+	if err != nil {
+		return nil, err
+	}
+	return loadFromDotenv(env), nil
+}
+
+// ParseData returns a ConfigContainer with the .env config map parsed from data.
+func (conf *Config) ParseData(data []byte) (config.Configer, error) {
+	env, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return loadFromDotenv(env), nil
+}
+
+func loadFromDotenv(env map[string]string) *ConfigEngine {
+	conf := ConfigEngine{Data: make(map[string]interface{}, len(env))}
+	for k, v := range env {
+		conf.Data[k] = v
+	}
+	return &conf
+}
+
+// EnvLayer returns the layer "env" of config.NewLayered over the variables
+// of NewEnvConfig(prefix). Its sections merge with those of lower layers
+// ignoring case, so APP_DB_MAXIDLE overrides db.maxIdle of a file.
+func EnvLayer(prefix string) config.Layer {
+	return config.Layer{Name: "env", Config: NewEnvConfig(prefix), FoldCase: true}
+}
+
+// NewEnvConfig returns a ConfigEngine over the process environment, see
+// EnvLayer. Only variables named prefix_* are
+// included, with the prefix removed, so that db::module is read from
+// APP_DB_MODULE when prefix is "APP". An empty prefix includes everything.
+func NewEnvConfig(prefix string) *ConfigEngine {
+	if prefix != "" {
+		prefix = envName(prefix) + "_"
+	}
+
+	conf := ConfigEngine{Data: make(map[string]interface{})}
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, prefix) || k == prefix {
+			continue
+		}
+		conf.Data[strings.TrimPrefix(k, prefix)] = v
+	}
+	return &conf
+}
+
+// ConfigEngine ...
+type ConfigEngine struct {
+	Data    map[string]interface{}
+	overlay []string // environment variables overlaid on Data, see config.Overlayer
+}
+
+// Bool returns the boolean value for a given key.
+func (conf *ConfigEngine) Bool(key string) (bool, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return false, err
+	}
+	return config.ParseBool(v)
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (conf *ConfigEngine) DefaultBool(key string, defaultval bool) bool {
+	v, err := conf.Bool(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int returns the integer value for a given key.
+func (conf *ConfigEngine) Int(key string) (int, error) {
+	v, err := conf.Int64(key)
+	return int(v), err
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt(key string, defaultval int) int {
+	v, err := conf.Int(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int64 returns the int64 value for a given key.
+func (conf *ConfigEngine) Int64(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseInt64(v)
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt64(key string, defaultval int64) int64 {
+	v, err := conf.Int64(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Float returns the float value for a given key.
+func (conf *ConfigEngine) Float(key string) (float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0.0, err
+	}
+	return config.ParseFloat(v)
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultFloat(key string, defaultval float64) float64 {
+	v, err := conf.Float(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// String returns the string value for a given key.
+func (conf *ConfigEngine) String(key string) string {
+	if v, err := conf.getData(key); err == nil {
+		if vv, ok := v.(string); ok {
+			return vv
+		}
+	}
+	return ""
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultString(key string, defaultval string) string {
+	v := conf.String(key)
+	if v == "" {
+		return defaultval
+	}
+	return v
+}
+
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
+func (conf *ConfigEngine) Strings(key string) []string {
+	val, err := conf.getData(key)
+	if err != nil {
+		return nil
+	}
+	v, err := config.ParseStrings(val)
+	if err != nil || len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultStrings(key string, defaultval []string) []string {
+	v := conf.Strings(key)
+	if v == nil {
+		return defaultval
+	}
+	return v
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (conf *ConfigEngine) Uint64(key string) (uint64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseUint64(v)
+}
+
+// Duration returns the time.Duration value, given as "1m30s", see config.ParseDuration for a given key.
+func (conf *ConfigEngine) Duration(key string) (time.Duration, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseDuration(v)
+}
+
+// Bytes returns the size in bytes, given as "16MB", "512KiB" or in bytes for a given key.
+func (conf *ConfigEngine) Bytes(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseBytes(v)
+}
+
+// Time returns the time.Time value, see config.ParseTime for a given key.
+func (conf *ConfigEngine) Time(key string) (time.Time, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.ParseTime(v)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *ConfigEngine) StringMap(key string) (map[string]string, error) {
+	if v, err := conf.getData(key); err == nil {
+		return config.ParseStringMap(v)
+	}
+	section, err := conf.section(key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := config.ResolveValue(section)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseStringMap(v)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) IntSlice(key string) ([]int, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseIntSlice(v)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) Float64Slice(key string) ([]float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseFloat64Slice(v)
+}
+
+// Sub returns the variables of the section at key, with the SECTION_ prefix
+// removed, as a new Configer. It returns nil if the section is empty.
+func (conf *ConfigEngine) Sub(key string) config.Configer {
+	section, err := conf.section(key)
+	if err != nil {
+		return nil
+	}
+	return &ConfigEngine{Data: section}
+}
+
+// Unmarshal decodes the section at key, or the whole config if key is empty,
+// into the struct pointed to by out.
+func (conf *ConfigEngine) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := config.ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return config.Decode(data, out)
+	}
+	if v, err := conf.getData(key); err == nil {
+		return config.Decode(v, out)
+	}
+
+	section, err := conf.section(key)
+	if err != nil {
+		return err
+	}
+	data, err := config.ResolveValue(section)
+	if err != nil {
+		return err
+	}
+	return config.Decode(data, out)
+}
+
+// section collects the variables sharing the SECTION_ prefix of key, with the
+// prefix removed.
+func (conf *ConfigEngine) section(key string) (map[string]interface{}, error) {
+	prefix := envName(key) + "_"
+	section := make(map[string]interface{})
+	for k, v := range conf.Data {
+		if strings.HasPrefix(k, prefix) {
+			section[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	if len(section) == 0 {
+		return nil, fmt.Errorf("not exist key %q", key)
+	}
+	return section, nil
+}
+
+// RawData returns the parsed config map.
+func (conf *ConfigEngine) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Set sets the value of key. A section::key or section.key path is stored as
+// the variable SECTION_KEY unless key itself already exists.
+func (conf *ConfigEngine) Set(key string, value interface{}) error {
+	if len(key) == 0 {
+		return fmt.Errorf("key is empty")
+	}
+	if conf.Data == nil {
+		conf.Data = make(map[string]interface{})
+	}
+	if _, ok := conf.Data[key]; !ok && strings.ContainsAny(key, ":.") {
+		key = envName(key)
+	}
+	conf.Data[key] = formatValue(value)
+	return nil
+}
+
+// Delete removes key from the config.
+func (conf *ConfigEngine) Delete(key string) error {
+	for _, k := range []string{key, envName(key)} {
+		if _, ok := conf.Data[k]; ok {
+			delete(conf.Data, k)
+			return nil
+		}
+	}
+	return fmt.Errorf("not exist key %q", key)
+}
+
+// SetOverlay records the environment variables overlaid on the config, see
+// config.Overlayer.
+func (conf *ConfigEngine) SetOverlay(vars []string) {
+	conf.overlay = vars
+}
+
+// Marshal returns the config in dotenv format.
+func (conf *ConfigEngine) Marshal() ([]byte, error) {
+	env := make(map[string]string, len(conf.Data))
+	for k, v := range conf.Data {
+		env[k] = formatValue(v)
+	}
+	content, err := godotenv.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content + "\n"), nil
+}
+
+// SaveConfigFile save the config into file
+// A config overlaid with environment variables cannot be saved, see
+// config.Overlayer.
+func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
+	if len(conf.overlay) > 0 {
+		return &config.OverlayError{Vars: conf.overlay}
+	}
+	b, err := conf.Marshal()
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(filename, b)
+}
+
+// getData looks up key as is, then a section::key or section.key path as the
+// variable SECTION_KEY, and resolves the secrets in its value.
+func (conf *ConfigEngine) getData(key string) (interface{}, error) {
+	if v, err := config.Lookup(conf.Data, key); err == nil {
+		return config.ResolveValue(v)
+	}
+	if v, ok := conf.Data[envName(key)]; ok {
+		return config.ResolveValue(v)
+	}
+	return nil, fmt.Errorf("not exist key %q", key)
+}
+
+// envName maps section::key and section.key to SECTION_KEY.
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("::", "_", ".", "_").Replace(key))
+}
+
+// formatValue writes lists the way Strings reads them back.
+func formatValue(v interface{}) string {
+	if list, ok := v.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ";")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,84 @@
+package dotenv_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	"github.com/fengfenghuo/go-common-lib/config/dotenv"
+)
+
+func TestDotenvConfig(t *testing.T) {
+	conf, err := config.NewConfig("dotenv", "conf.env")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v := conf.String("APPNAME"); v != "go-bill-server" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, err := conf.Int("HTTPPORT"); err != nil || v != 38080 {
+		t.Errorf("httpport = %d, %v", v, err)
+	}
+	if v := conf.String("db::module"); v != "bee" {
+		t.Errorf("db::module = %q", v)
+	}
+	if v := conf.DefaultInt("db::maxIdle", 1); v != 50 {
+		t.Errorf("db::maxIdle = %d", v)
+	}
+	if v := conf.DefaultString("db::nothing", "x"); v != "x" {
+		t.Errorf("db::nothing = %q", v)
+	}
+	var db struct {
+		Module  string `config:"module"`
+		MaxConn int    `config:"maxConn"`
+	}
+	if err := conf.Unmarshal("db", &db); err != nil || db.Module != "bee" || db.MaxConn != 300 {
+		t.Errorf("Unmarshal db = %+v, %v", db, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "saved.env")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	saved, err := config.NewConfig("dotenv", file)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := saved.String("db::link"); v != conf.String("db::link") {
+		t.Errorf("saved db::link = %q", v)
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	t.Setenv("APP_DB_MODULE", "orm")
+
+	file, err := config.NewConfig("dotenv", "conf.env")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	conf := config.NewLayered(config.NewLayer("file", file), dotenv.EnvLayer("APP"))
+
+	if v := conf.String("db::module"); v != "orm" {
+		t.Errorf("db::module = %q, want orm", v)
+	}
+	if name, _ := conf.Source("db::module"); name != "env" {
+		t.Errorf("db::module comes from layer %q, want env", name)
+	}
+	if v := conf.String("db::link"); v != file.String("db::link") {
+		t.Errorf("db::link = %q", v)
+	}
+}
+
+func TestDotenvFromBytes(t *testing.T) {
+	conf, err := config.NewConfigFromBytes("dotenv", []byte("APPNAME=bill\nDB_MAXIDLE=50\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("APPNAME"); v != "bill" {
+		t.Errorf("APPNAME = %q", v)
+	}
+	if v, err := conf.Int("db.maxIdle"); err != nil || v != 50 {
+		t.Errorf("db.maxIdle = %d, %v", v, err)
+	}
+}
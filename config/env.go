@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Overlayer is implemented by the Configers that support WithEnv. The
+// variables NewConfig overlaid on the raw data are passed to SetOverlay;
+// SaveConfigFile then fails with an *OverlayError rather than write their
+// values, which may be secrets replacing a placeholder, to the file.
+type Overlayer interface {
+	DataContainer
+	SetOverlay(vars []string)
+}
+
+// OverlayError is returned by SaveConfigFile for a config with values taken
+// from environment variables by WithEnv.
+type OverlayError struct {
+	Vars []string
+}
+
+func (e *OverlayError) Error() string {
+	return "config: cannot save a config overlaid with " + strings.Join(e.Vars, ", ")
+}
+
+// overlayEnv replaces every leaf value of data for which an environment
+// variable exists. The variable name is the prefix followed by the key path,
+// upper-cased and joined by "_": db::module (json) or db.module (yaml) is read
+// from APP_DB_MODULE with prefix "APP". Only keys present in data are looked
+// up. Values are coerced to the type of the value they replace. The names of
+// the variables used are returned.
+func overlayEnv(data map[string]interface{}, prefix string) ([]string, error) {
+	var vars []string
+	err := overlayEnvMap(data, envName(prefix), &vars)
+	return vars, err
+}
+
+func overlayEnvMap(data map[string]interface{}, prefix string, vars *[]string) error {
+	for k, v := range data {
+		val, set, err := overlayEnvValue(v, joinEnvName(prefix, k), vars)
+		if err != nil {
+			return err
+		}
+		if set {
+			data[k] = val
+		}
+	}
+	return nil
+}
+
+func overlayEnvValue(v interface{}, name string, vars *[]string) (interface{}, bool, error) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return nil, false, overlayEnvMap(m, name, vars)
+	case map[interface{}]interface{}:
+		for k, vv := range m {
+			val, set, err := overlayEnvValue(vv, joinEnvName(name, fmt.Sprint(k)), vars)
+			if err != nil {
+				return nil, false, err
+			}
+			if set {
+				m[k] = val
+			}
+		}
+		return nil, false, nil
+	}
+
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, false, nil
+	}
+	val, err := coerceEnv(v, s)
+	if err != nil {
+		return nil, false, fmt.Errorf("config: env %s: %s", name, err.Error())
+	}
+	*vars = append(*vars, name)
+	return val, true, nil
+}
+
+// coerceEnv converts s to the type of old, using ParseBool for booleans and
+// ";" as the separator for lists, the same as Strings.
+func coerceEnv(old interface{}, s string) (interface{}, error) {
+	switch v := old.(type) {
+	case bool:
+		return ParseBool(s)
+	case int:
+		return strconv.Atoi(s)
+	case int64:
+		return strconv.ParseInt(s, 10, 64)
+	case uint64:
+		return strconv.ParseUint(s, 10, 64)
+	case float64:
+		return strconv.ParseFloat(s, 64)
+	case []interface{}:
+		var elem interface{}
+		if len(v) > 0 {
+			elem = v[0]
+		}
+		parts := strings.Split(s, ";")
+		res := make([]interface{}, len(parts))
+		for i, p := range parts {
+			val, err := coerceEnv(elem, p)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+		return res, nil
+	}
+	return s, nil
+}
+
+func joinEnvName(prefix, key string) string {
+	if prefix == "" {
+		return envName(key)
+	}
+	return prefix + "_" + envName(key)
+}
+
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// IncludeKey is the top-level key listing the files, or glob patterns
+	// such as conf.d/*.yaml, whose content a config file builds upon.
+	IncludeKey = "include"
+	// RefKey replaces the map it appears in by the content of another file,
+	// or by a section of it with file.yaml#db.primary.
+	RefKey = "$ref"
+)
+
+// Loader parses a single config file into its raw data.
+type Loader func(filename string) (map[string]interface{}, error)
+
+// Compose resolves the include and $ref directives of data, parsed from
+// filename, loading the referenced files with load. Paths are relative to the
+// file that references them, and included files may include others.
+//
+// Included files are merged in the order they are listed, the matches of a
+// glob in lexical order, and the including file is merged last. Maps are
+// merged key by key; any other value, lists included, replaces the one of
+// the files merged before. Keys next to a $ref are merged over the referenced
+// content the same way.
+func Compose(filename string, data map[string]interface{}, load Loader) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	return compose(nil, abs, data, load)
+}
+
+// compose is Compose for the files of fsys, or of the operating system if
+// fsys is nil. An empty filename stands for data not read from a file, whose
+// paths are relative to the working directory or the root of fsys.
+func compose(fsys fs.FS, filename string, data map[string]interface{}, load Loader) (map[string]interface{}, error) {
+	return newComposer(fsys, filename, load).compose(filename, data)
+}
+
+func newComposer(fsys fs.FS, filename string, load Loader) *composer {
+	c := &composer{fsys: fsys, load: load, active: make(map[string]bool)}
+	if filename != "" {
+		c.active[filename] = true
+	}
+	return c
+}
+
+// Includer is implemented by the Configers of the adapters whose files may
+// use the include and $ref directives, json and yaml. NewConfig resolves the
+// directives in the raw data of these Configers only, an include key of an
+// ini, toml or dotenv file is an ordinary key. The files merged into the
+// config are passed to SetIncludes; SaveConfigFile then fails rather than
+// write their content into a single file without the directives. A Watcher
+// watches the files returned by Includes along with the config file.
+type Includer interface {
+	DataContainer
+	SetIncludes(files []string)
+	Includes() []string
+}
+
+// ComposedError is returned by SaveConfigFile for a config composed from
+// other files: saving it would copy their content into a single file and
+// drop the include and $ref directives.
+type ComposedError struct {
+	Files []string
+}
+
+func (e *ComposedError) Error() string {
+	return "config: cannot save a config composed from " + strings.Join(e.Files, ", ")
+}
+
+// composeData resolves the include and $ref directives of the raw data of
+// conf in place, loading the referenced files with adapter.
+func composeData(conf Configer, adapter Config, fsys fs.FS, filename string) error {
+	ic, ok := conf.(Includer)
+	if !ok || ic.RawData() == nil {
+		return nil
+	}
+	read := os.ReadFile
+	if fsys != nil {
+		read = func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		}
+	}
+	load := func(name string) (map[string]interface{}, error) {
+		b, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		c, err := adapter.ParseData(b)
+		if err != nil {
+			return nil, err
+		}
+		if dc, ok := c.(DataContainer); ok {
+			return dc.RawData(), nil
+		}
+		return nil, fmt.Errorf("adapter does not support includes")
+	}
+
+	data := ic.RawData()
+	c := newComposer(fsys, filename, load)
+	composed, err := c.compose(filename, data)
+	if err != nil {
+		return err
+	}
+	for k := range data {
+		delete(data, k)
+	}
+	for k, v := range composed {
+		data[k] = v
+	}
+	if len(c.files) > 0 {
+		ic.SetIncludes(c.files)
+	}
+	return nil
+}
+
+type composer struct {
+	fsys   fs.FS
+	load   Loader
+	active map[string]bool // files being composed, to detect cycles
+	files  []string        // files loaded, each once
+}
+
+// resolve returns the path of name referenced from the file from.
+func (c *composer) resolve(from, name string) string {
+	if c.fsys != nil {
+		if from == "" {
+			return path.Clean(name)
+		}
+		return path.Join(path.Dir(from), name)
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if from == "" {
+		if abs, err := filepath.Abs(name); err == nil {
+			return abs
+		}
+		return name
+	}
+	return filepath.Join(filepath.Dir(from), name)
+}
+
+func (c *composer) compose(filename string, data map[string]interface{}) (map[string]interface{}, error) {
+	patterns, err := includePatterns(data[IncludeKey])
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %s", displayName(filename), err.Error())
+	}
+
+	var merged interface{}
+	for _, pattern := range patterns {
+		files, err := c.expand(filename, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			included, err := c.loadFile(filename, file)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeValues(merged, included)
+		}
+	}
+
+	own := copyMap(data)
+	delete(own, IncludeKey)
+	resolved, err := c.resolveRefs(filename, own)
+	if err != nil {
+		return nil, err
+	}
+	res, _ := toStringMap(mergeValues(merged, resolved))
+	return res, nil
+}
+
+// expand returns the files matched by pattern, relative to the directory of
+// filename. A glob may match nothing, a plain path must exist.
+func (c *composer) expand(filename, pattern string) ([]string, error) {
+	pattern = c.resolve(filename, pattern)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+	var files []string
+	var err error
+	if c.fsys != nil {
+		files, err = fs.Glob(c.fsys, pattern)
+	} else {
+		files, err = filepath.Glob(pattern)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: include %q: %s", displayName(filename), pattern, err.Error())
+	}
+	return files, nil
+}
+
+// loadFile loads and composes file, referenced from filename.
+func (c *composer) loadFile(from, file string) (map[string]interface{}, error) {
+	if c.active[file] {
+		return nil, fmt.Errorf("config: %s: include cycle through %s", displayName(from), file)
+	}
+	data, err := c.load(file)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: include %s: %s", displayName(from), file, err.Error())
+	}
+
+	if !containsString(c.files, file) {
+		c.files = append(c.files, file)
+	}
+	c.active[file] = true
+	defer delete(c.active, file)
+	return c.compose(file, data)
+}
+
+// resolveRefs replaces the $ref maps below v.
+func (c *composer) resolveRefs(filename string, v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, vv := range val {
+			if k == RefKey {
+				continue
+			}
+			r, err := c.resolveRefs(filename, vv)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		ref, ok := val[RefKey]
+		if !ok {
+			return res, nil
+		}
+		s, ok := ref.(string)
+		if !ok {
+			return nil, fmt.Errorf("config: %s: %s must be a string, got %T", displayName(filename), RefKey, ref)
+		}
+		target, err := c.ref(filename, s)
+		if err != nil {
+			return nil, err
+		}
+		if len(res) == 0 {
+			return target, nil
+		}
+		return mergeValues(target, res), nil
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, vv := range val {
+			r, err := c.resolveRefs(filename, vv)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = r
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// ref loads the value referenced by file#key.
+func (c *composer) ref(filename, ref string) (interface{}, error) {
+	file, key, _ := strings.Cut(ref, "#")
+	if file == "" {
+		return nil, fmt.Errorf("config: %s: %s %q does not name a file", displayName(filename), RefKey, ref)
+	}
+	data, err := c.loadFile(filename, c.resolve(filename, file))
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return data, nil
+	}
+	v, err := Lookup(data, key)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %s %q: %s", displayName(filename), RefKey, ref, err.Error())
+	}
+	return v, nil
+}
+
+// displayName names the config data that was not read from a file.
+func displayName(filename string) string {
+	if filename == "" {
+		return "<data>"
+	}
+	return filename
+}
+
+// includePatterns accepts a single pattern or a list of them.
+func includePatterns(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		res := make([]string, len(val))
+		for i, p := range val {
+			s, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a string, got %T", IncludeKey, i, p)
+			}
+			res[i] = s
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("%s must be a string or a list, got %T", IncludeKey, v)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	files := map[string]map[string]interface{}{
+		"/etc/app/base.json": {
+			"db":      map[string]interface{}{"host": "localhost", "port": 3306.0},
+			"servers": []interface{}{"a", "b"},
+		},
+		"/etc/app/secrets.json": {
+			"primary": map[string]interface{}{"user": "root", "password": "x"},
+		},
+	}
+	load := func(filename string) (map[string]interface{}, error) {
+		if data, ok := files[filename]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("no such file")
+	}
+
+	data := map[string]interface{}{
+		"include": "base.json",
+		"db":      map[string]interface{}{"port": 3307.0},
+		"servers": []interface{}{"c"},
+		"auth":    map[string]interface{}{"$ref": "secrets.json#primary", "user": "app"},
+	}
+	got, err := Compose("/etc/app/conf.json", data, load)
+	if err != nil {
+		t.Fatalf("Compose error: %v", err)
+	}
+	want := map[string]interface{}{
+		"db":      map[string]interface{}{"host": "localhost", "port": 3307.0},
+		"servers": []interface{}{"c"},
+		"auth":    map[string]interface{}{"user": "app", "password": "x"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compose = %v, want %v", got, want)
+	}
+	if _, ok := data["include"]; !ok {
+		t.Error("Compose modified its argument")
+	}
+}
+
+func TestComposeCycle(t *testing.T) {
+	load := func(filename string) (map[string]interface{}, error) {
+		next := "a.json"
+		if filepath.Base(filename) == "a.json" {
+			next = "b.json"
+		}
+		return map[string]interface{}{"include": next}, nil
+	}
+	_, err := Compose("/etc/app/a.json", map[string]interface{}{"include": "b.json"}, load)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Compose error = %v, want include cycle", err)
+	}
+}
@@ -0,0 +1,431 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	"gopkg.in/ini.v1"
+)
+
+func init() {
+	config.Register("ini", &Config{})
+}
+
+// Config is an ini config parser and implements Config interface.
+type Config struct{}
+
+// Parse returns a ConfigContainer with parsed ini config map.
+func (conf *Config) Parse(filename string) (config.Configer, error) {
+	return loadFromIni(filename)
+}
+
+// ParseData returns a ConfigContainer with the ini config map parsed from data.
+func (conf *Config) ParseData(data []byte) (config.Configer, error) {
+	return loadFromIni(data)
+}
+
+// loadFromIni keeps the keys of the default section at the top level and
+// the keys of every other section in a nested map, addressed as section::key.
+// source is a file name or the content, as accepted by ini.Load.
+func loadFromIni(source interface{}) (*ConfigEngine, error) {
+	file, err := ini.Load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := ConfigEngine{Data: make(map[string]interface{})}
+	for _, section := range file.Sections() {
+		data := conf.Data
+		if section.Name() != ini.DefaultSection {
+			data = make(map[string]interface{})
+			conf.Data[section.Name()] = data
+		}
+		for _, key := range section.Keys() {
+			data[key.Name()] = key.Value()
+		}
+	}
+	return &conf, nil
+}
+
+// ConfigEngine ...
+type ConfigEngine struct {
+	Data    map[string]interface{}
+	overlay []string // environment variables overlaid on Data, see config.Overlayer
+}
+
+// Bool returns the boolean value for a given key.
+func (conf *ConfigEngine) Bool(key string) (bool, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return false, err
+	}
+	return config.ParseBool(v)
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (conf *ConfigEngine) DefaultBool(key string, defaultval bool) bool {
+	v, err := conf.Bool(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int returns the integer value for a given key.
+func (conf *ConfigEngine) Int(key string) (int, error) {
+	v, err := conf.Int64(key)
+	return int(v), err
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt(key string, defaultval int) int {
+	v, err := conf.Int(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int64 returns the int64 value for a given key.
+func (conf *ConfigEngine) Int64(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseInt64(v)
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt64(key string, defaultval int64) int64 {
+	v, err := conf.Int64(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Float returns the float value for a given key.
+func (conf *ConfigEngine) Float(key string) (float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0.0, err
+	}
+	return config.ParseFloat(v)
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultFloat(key string, defaultval float64) float64 {
+	v, err := conf.Float(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// String returns the string value for a given key.
+func (conf *ConfigEngine) String(key string) string {
+	if v, err := conf.getData(key); err == nil {
+		if vv, ok := v.(string); ok {
+			return vv
+		}
+	}
+	return ""
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultString(key string, defaultval string) string {
+	v := conf.String(key)
+	if v == "" {
+		return defaultval
+	}
+	return v
+}
+
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
+func (conf *ConfigEngine) Strings(key string) []string {
+	val, err := conf.getData(key)
+	if err != nil {
+		return nil
+	}
+	v, err := config.ParseStrings(val)
+	if err != nil || len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultStrings(key string, defaultval []string) []string {
+	v := conf.Strings(key)
+	if v == nil {
+		return defaultval
+	}
+	return v
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (conf *ConfigEngine) Uint64(key string) (uint64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseUint64(v)
+}
+
+// Duration returns the time.Duration value, given as "1m30s", see config.ParseDuration for a given key.
+func (conf *ConfigEngine) Duration(key string) (time.Duration, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseDuration(v)
+}
+
+// Bytes returns the size in bytes, given as "16MB", "512KiB" or in bytes for a given key.
+func (conf *ConfigEngine) Bytes(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseBytes(v)
+}
+
+// Time returns the time.Time value, see config.ParseTime for a given key.
+func (conf *ConfigEngine) Time(key string) (time.Time, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.ParseTime(v)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *ConfigEngine) StringMap(key string) (map[string]string, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseStringMap(v)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) IntSlice(key string) ([]int, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseIntSlice(v)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) Float64Slice(key string) ([]float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseFloat64Slice(v)
+}
+
+// Sub returns the section at key as a Configer sharing its data, so Set on
+// it changes this config too. It returns nil if key is not a section.
+func (conf *ConfigEngine) Sub(key string) config.Configer {
+	v, err := config.Lookup(conf.Data, key)
+	if m, ok := v.(map[string]interface{}); ok && err == nil {
+		return &ConfigEngine{Data: m}
+	}
+	return nil
+}
+
+// Unmarshal decodes the section at key, or the whole config if key is empty,
+// into the struct pointed to by out.
+func (conf *ConfigEngine) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := config.ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return config.Decode(data, out)
+	}
+	v, err := conf.getData(key)
+	if err != nil {
+		return err
+	}
+	return config.Decode(v, out)
+}
+
+// RawData returns the parsed config map.
+func (conf *ConfigEngine) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Set sets the value of key, creating missing sections. Values are stored
+// as strings, the way they are read from an ini file, and maps as sections.
+func (conf *ConfigEngine) Set(key string, value interface{}) error {
+	if conf.Data == nil {
+		conf.Data = make(map[string]interface{})
+	}
+	v, err := iniValue(key, value)
+	if err != nil {
+		return err
+	}
+	return config.SetPath(conf.Data, key, v)
+}
+
+// iniValue converts value to strings and sections of strings.
+func iniValue(key string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, vv := range v {
+			val, err := iniValue(key+"."+k, vv)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = val
+		}
+		return m, nil
+	case []interface{}:
+		if err := checkList(key, v); err != nil {
+			return nil, err
+		}
+	}
+	return formatValue(value), nil
+}
+
+// checkList fails for a list of sections or lists, which ini cannot
+// represent.
+func checkList(key string, list []interface{}) error {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			return fmt.Errorf("config: ini cannot represent the nested list %s", key)
+		}
+	}
+	return nil
+}
+
+// Delete removes key from the config.
+func (conf *ConfigEngine) Delete(key string) error {
+	return config.DeletePath(conf.Data, key)
+}
+
+// SetOverlay records the environment variables overlaid on the config, see
+// config.Overlayer.
+func (conf *ConfigEngine) SetOverlay(vars []string) {
+	conf.overlay = vars
+}
+
+// Marshal returns the config in ini. Sections below sections are written as
+// [db.pool], which Lookup reads back as db.pool; lists of sections cannot be
+// written.
+func (conf *ConfigEngine) Marshal() ([]byte, error) {
+	file := ini.Empty()
+	if err := addSection(file, ini.DefaultSection, conf.Data); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveConfigFile save the config into file, see Marshal.
+// A config overlaid with environment variables cannot be saved, see
+// config.Overlayer.
+func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
+	if len(conf.overlay) > 0 {
+		return &config.OverlayError{Vars: conf.overlay}
+	}
+	b, err := conf.Marshal()
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(filename, b)
+}
+
+// getData resolves key with config.Lookup and the secrets in its value with
+// config.ResolveValue.
+func (conf *ConfigEngine) getData(key string) (interface{}, error) {
+	v, err := config.Lookup(conf.Data, key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ResolveValue(v)
+}
+
+// addSection writes the values of m to the section name, then the maps among
+// them to sections named name.key.
+func addSection(file *ini.File, name string, m map[string]interface{}) error {
+	s := file.Section(name)
+	if name != ini.DefaultSection {
+		var err error
+		if s, err = file.NewSection(name); err != nil {
+			return err
+		}
+	}
+
+	var sections []string
+	for _, k := range sortedKeys(m) {
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			sections = append(sections, k)
+			continue
+		case map[interface{}]interface{}:
+			return fmt.Errorf("config: ini: %s is not a section with string keys", joinSection(name, k))
+		case []interface{}:
+			if err := checkList(joinSection(name, k), v); err != nil {
+				return err
+			}
+		}
+		if _, err := s.NewKey(k, formatValue(m[k])); err != nil {
+			return err
+		}
+	}
+	for _, k := range sections {
+		if err := addSection(file, joinSection(name, k), m[k].(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinSection(name, key string) string {
+	if name == ini.DefaultSection {
+		return key
+	}
+	return name + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue writes lists the way Strings reads them back.
+func formatValue(v interface{}) string {
+	if list, ok := v.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ";")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,111 @@
+package ini_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func TestIniConfig(t *testing.T) {
+	conf, err := config.NewConfig("ini", "conf.ini")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v := conf.String("appname"); v != "go-bill-server" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, err := conf.Int("httpport"); err != nil || v != 38080 {
+		t.Errorf("httpport = %d, %v", v, err)
+	}
+	if v := conf.String("db::module"); v != "bee" {
+		t.Errorf("db::module = %q", v)
+	}
+	if v := conf.DefaultInt("db::maxIdle", 1); v != 50 {
+		t.Errorf("db::maxIdle = %d", v)
+	}
+	if v := conf.DefaultString("db::nothing", "x"); v != "x" {
+		t.Errorf("db::nothing = %q", v)
+	}
+	if _, err := conf.Int("appname::module"); err == nil {
+		t.Errorf("expected error for key below a value")
+	}
+
+	file := filepath.Join(t.TempDir(), "saved.ini")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	saved, err := config.NewConfig("ini", file)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := saved.String("db::link"); v != conf.String("db::link") {
+		t.Errorf("saved db::link = %q", v)
+	}
+}
+
+func TestIniFromBytes(t *testing.T) {
+	conf, err := config.NewConfigFromBytes("ini", []byte("appname = bill\n[db]\nmaxIdle = 50\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("appname"); v != "bill" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, err := conf.Int("db::maxIdle"); err != nil || v != 50 {
+		t.Errorf("db::maxIdle = %d, %v", v, err)
+	}
+}
+
+func TestIniDottedSection(t *testing.T) {
+	conf, err := config.NewConfigFromBytes("ini", []byte("[server.http]\nport = 8080\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v, err := conf.Int("server.http::port"); err != nil || v != 8080 {
+		t.Errorf("server.http::port = %d, %v", v, err)
+	}
+	if v, err := conf.Int("server.http.port"); err != nil || v != 8080 {
+		t.Errorf("server.http.port = %d, %v", v, err)
+	}
+	if sub := conf.Sub("server.http"); sub == nil || sub.DefaultInt("port", 0) != 8080 {
+		t.Errorf("Sub(server.http) = %v", sub)
+	}
+}
+
+func TestIniNestedSections(t *testing.T) {
+	conf, err := config.NewConfigFromBytes("ini", []byte("appname = bill\n[db]\nmodule = bee\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if err := conf.Set("db.pool", map[string]interface{}{"max": 5, "idle": map[string]interface{}{"min": 1}}); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "saved.ini")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	saved, err := config.NewConfig("ini", file)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v, err := saved.Int("db.pool.max"); err != nil || v != 5 {
+		t.Errorf("saved db.pool.max = %d, %v", v, err)
+	}
+	if v, err := saved.Int("db.pool.idle.min"); err != nil || v != 1 {
+		t.Errorf("saved db.pool.idle.min = %d, %v", v, err)
+	}
+	if v := saved.String("db.module"); v != "bee" {
+		t.Errorf("saved db.module = %q", v)
+	}
+
+	servers := []interface{}{map[string]interface{}{"host": "a"}}
+	if err := conf.Set("servers", servers); err == nil {
+		t.Error("Set of a list of sections succeeded")
+	}
+	conf.(config.DataContainer).RawData()["servers"] = servers
+	if err := conf.SaveConfigFile(file); err == nil {
+		t.Error("SaveConfigFile of a list of sections succeeded")
+	}
+}
@@ -1,11 +1,11 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
-	"strings"
+	"time"
 
 	"github.com/fengfenghuo/go-common-lib/config"
 )
@@ -19,26 +19,82 @@ type Config struct{}
 
 // Parse returns a ConfigContainer with parsed json config map.
 func (conf *Config) Parse(filename string) (config.Configer, error) {
-	return loadFromJSON(filename)
-}
-
-func loadFromJSON(filename string) (*ConfigEngine, error) {
 	file, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	return loadFromJSON(filename, file)
+}
 
+// ParseData returns a ConfigContainer with the json config map parsed from data.
+func (conf *Config) ParseData(data []byte) (config.Configer, error) {
+	return loadFromJSON("", data)
+}
+
+func loadFromJSON(filename string, file []byte) (*ConfigEngine, error) {
 	var conf ConfigEngine
-	err = json.Unmarshal(file, &conf.Data)
+	err := json.Unmarshal(file, &conf.Data)
 	if err != nil {
 		return nil, err
 	}
+	conf.positions = jsonPositions(filename, file)
 	return &conf, nil
 }
 
+// jsonPositions records the line of every key of the already validated json
+// document file.
+func jsonPositions(filename string, file []byte) *config.Positions {
+	pos := config.NewPositions(filename)
+	dec := json.NewDecoder(bytes.NewReader(file))
+	line := func() int {
+		return bytes.Count(file[:dec.InputOffset()], []byte("\n")) + 1
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if path != "" {
+			pos.Add(path, line())
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := fmt.Sprint(key)
+				if path != "" {
+					child = path + "." + child
+				}
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+	return pos
+}
+
 // ConfigEngine ...
 type ConfigEngine struct {
-	Data map[string]interface{}
+	Data      map[string]interface{}
+	positions *config.Positions
+	includes  []string // files composed into Data, see config.Includer
+	overlay   []string // environment variables overlaid on Data, see config.Overlayer
 }
 
 // Bool returns the boolean value for a given key.
@@ -61,14 +117,8 @@ func (conf *ConfigEngine) DefaultBool(key string, defaultval bool) bool {
 
 // Int returns the integer value for a given key.
 func (conf *ConfigEngine) Int(key string) (int, error) {
-	val := conf.getData(key)
-	if val != nil {
-		if v, ok := val.(float64); ok {
-			return int(v), nil
-		}
-		return 0, fmt.Errorf("not int value")
-	}
-	return 0, fmt.Errorf("not exist key:" + key)
+	v, err := conf.Int64(key)
+	return int(v), err
 }
 
 // DefaultInt returns the integer value for a given key.
@@ -83,13 +133,14 @@ func (conf *ConfigEngine) DefaultInt(key string, defaultval int) int {
 // Int64 returns the int64 value for a given key.
 func (conf *ConfigEngine) Int64(key string) (int64, error) {
 	val := conf.getData(key)
-	if val != nil {
-		if v, ok := val.(float64); ok {
-			return int64(v), nil
-		}
-		return 0, fmt.Errorf("not int64 value")
+	if val == nil {
+		return 0, fmt.Errorf("not exist key: %q", key)
 	}
-	return 0, fmt.Errorf("not exist key:" + key)
+	v, err := config.ParseInt64(val)
+	if err != nil {
+		return 0, fmt.Errorf("not int value: %q is %#v", key, val)
+	}
+	return v, nil
 }
 
 // DefaultInt64 returns the int64 value for a given key.
@@ -104,13 +155,14 @@ func (conf *ConfigEngine) DefaultInt64(key string, defaultval int64) int64 {
 // Float returns the float value for a given key.
 func (conf *ConfigEngine) Float(key string) (float64, error) {
 	val := conf.getData(key)
-	if val != nil {
-		if v, ok := val.(float64); ok {
-			return v, nil
-		}
-		return 0.0, fmt.Errorf("not float64 value")
+	if val == nil {
+		return 0.0, fmt.Errorf("not exist key: %q", key)
 	}
-	return 0.0, fmt.Errorf("not exist key:" + key)
+	v, err := config.ParseFloat(val)
+	if err != nil {
+		return 0.0, fmt.Errorf("not float64 value: %q is %#v", key, val)
+	}
+	return v, nil
 }
 
 // DefaultFloat returns the float64 value for a given key.
@@ -143,13 +195,14 @@ func (conf *ConfigEngine) DefaultString(key string, defaultval string) string {
 	return defaultval
 }
 
-// Strings returns the []string value for a given key.
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
 func (conf *ConfigEngine) Strings(key string) []string {
-	stringVal := conf.String(key)
-	if stringVal == "" {
+	v, err := config.ParseStrings(conf.getData(key))
+	if err != nil || len(v) == 0 {
 		return nil
 	}
-	return strings.Split(conf.String(key), ";")
+	return v
 }
 
 // DefaultStrings returns the []string value for a given key.
@@ -161,45 +214,175 @@ func (conf *ConfigEngine) DefaultStrings(key string, defaultval []string) []stri
 	return defaultval
 }
 
-// SaveConfigFile save the config into file
-func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
-	// Write configuration file by filename.
-	f, err := os.Create(filename)
+// Uint64 returns the uint64 value for a given key.
+func (conf *ConfigEngine) Uint64(key string) (uint64, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return 0, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseUint64(val)
+}
+
+// Duration returns the time.Duration value, given as "1m30s", see config.ParseDuration for a given key.
+func (conf *ConfigEngine) Duration(key string) (time.Duration, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return 0, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseDuration(val)
+}
+
+// Bytes returns the size in bytes, given as "16MB", "512KiB" or in bytes for a given key.
+func (conf *ConfigEngine) Bytes(key string) (int64, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return 0, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseBytes(val)
+}
+
+// Time returns the time.Time value, see config.ParseTime for a given key.
+func (conf *ConfigEngine) Time(key string) (time.Time, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return time.Time{}, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseTime(val)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *ConfigEngine) StringMap(key string) (map[string]string, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return nil, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseStringMap(val)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) IntSlice(key string) ([]int, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return nil, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseIntSlice(val)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) Float64Slice(key string) ([]float64, error) {
+	val := conf.getData(key)
+	if val == nil {
+		return nil, fmt.Errorf("not exist key: %q", key)
+	}
+	return config.ParseFloat64Slice(val)
+}
+
+// Sub returns the section at key as a Configer sharing its data, so Set on
+// it changes this config too. It returns nil if key is not a section.
+func (conf *ConfigEngine) Sub(key string) config.Configer {
+	v, err := config.Lookup(conf.Data, key)
+	if m, ok := v.(map[string]interface{}); ok && err == nil {
+		return &ConfigEngine{Data: m}
+	}
+	return nil
+}
+
+// Unmarshal decodes the section at key, or the whole config if key is empty,
+// into the struct pointed to by out.
+func (conf *ConfigEngine) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := config.ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return config.Decode(data, out)
+	}
+	val := conf.getData(key)
+	if val == nil {
+		return fmt.Errorf("not exist key: %q", key)
+	}
+	return config.Decode(val, out)
+}
+
+// RawData returns the parsed config map.
+func (conf *ConfigEngine) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Locate returns the file and line where key is defined.
+func (conf *ConfigEngine) Locate(key string) (string, int, bool) {
+	return conf.positions.Locate(key)
+}
+
+// Set sets the value of key, creating missing sections. The value is stored
+// the way encoding/json decodes it, so the getters treat it like parsed data.
+func (conf *ConfigEngine) Set(key string, value interface{}) error {
+	b, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	b, err := json.MarshalIndent(conf.Data, "", "  ")
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if conf.Data == nil {
+		conf.Data = make(map[string]interface{})
+	}
+	return config.SetPath(conf.Data, key, v)
+}
+
+// Delete removes key from the config.
+func (conf *ConfigEngine) Delete(key string) error {
+	return config.DeletePath(conf.Data, key)
+}
+
+// SetIncludes records the files composed into the config, see config.Includer.
+func (conf *ConfigEngine) SetIncludes(files []string) {
+	conf.includes = files
+}
+
+// Includes returns the files composed into the config.
+func (conf *ConfigEngine) Includes() []string {
+	return conf.includes
+}
+
+// SetOverlay records the environment variables overlaid on the config, see
+// config.Overlayer.
+func (conf *ConfigEngine) SetOverlay(vars []string) {
+	conf.overlay = vars
+}
+
+// Marshal returns the config in json, indented by two spaces.
+func (conf *ConfigEngine) Marshal() ([]byte, error) {
+	return json.MarshalIndent(conf.Data, "", "  ")
+}
+
+// SaveConfigFile save the config into file
+// A config composed from other files or overlaid with environment
+// variables cannot be saved, see config.Includer and config.Overlayer.
+func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
+	if len(conf.overlay) > 0 {
+		return &config.OverlayError{Vars: conf.overlay}
+	}
+	if len(conf.includes) > 0 {
+		return &config.ComposedError{Files: conf.includes}
+	}
+	b, err := conf.Marshal()
 	if err != nil {
 		return err
 	}
-	_, err = f.Write(b)
-	return err
+	return config.WriteFile(filename, b)
 }
 
-// section.key or key
+// getData resolves key with config.Lookup and the secrets in its value with
+// config.ResolveValue, nil if it does not exist.
 func (conf *ConfigEngine) getData(key string) interface{} {
-	if len(key) == 0 {
+	v, err := config.Lookup(conf.Data, key)
+	if err != nil {
 		return nil
 	}
-
-	sectionKeys := strings.Split(key, "::")
-	if len(sectionKeys) >= 2 {
-		curValue, ok := conf.Data[sectionKeys[0]]
-		if !ok {
-			return nil
-		}
-		for _, key := range sectionKeys[1:] {
-			if v, ok := curValue.(map[string]interface{}); ok {
-				if curValue, ok = v[key]; !ok {
-					return nil
-				}
-			}
-		}
-		return curValue
-	}
-	if v, ok := conf.Data[key]; ok {
-		return v
+	if v, err = config.ResolveValue(v); err != nil {
+		return nil
 	}
-	return nil
+	return v
 }
@@ -1,8 +1,15 @@
 package json_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/fengfenghuo/go-common-lib/config"
 )
@@ -16,3 +23,433 @@ func TestJsonConfig(t *testing.T) {
 	fmt.Println(conf.Int("httpport"))
 	fmt.Println(conf.String("db::module"))
 }
+
+type dbConfig struct {
+	Module  string        `json:"module"`
+	Link    string        `json:"link"`
+	MaxIdle int           `json:"maxIdle"`
+	MaxConn int           `json:"maxConn"`
+	Timeout time.Duration `json:"timeout" default:"3s"`
+}
+
+func TestJsonUnmarshal(t *testing.T) {
+	conf, err := config.NewConfig("json", "conf.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	var db dbConfig
+	if err := conf.Unmarshal("db", &db); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if db.Module != "bee" || db.MaxIdle != 50 || db.MaxConn != 300 || db.Timeout != 3*time.Second {
+		t.Errorf("unexpected db config: %+v", db)
+	}
+
+	var app struct {
+		AppName  string   `config:"appname"`
+		HTTPPort int      `config:"httpport"`
+		DB       dbConfig `config:"db"`
+	}
+	if err := conf.Unmarshal("", &app); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if app.AppName != "go-bill-server" || app.HTTPPort != 38080 || app.DB.Module != "bee" {
+		t.Errorf("unexpected app config: %+v", app)
+	}
+
+	if err := conf.Unmarshal("nosuchkey", &db); err == nil {
+		t.Errorf("expected error for missing key")
+	}
+}
+
+func TestJsonEnvOverlay(t *testing.T) {
+	t.Setenv("APP_DB_MODULE", "orm")
+	t.Setenv("APP_DB_MAXIDLE", "10")
+	t.Setenv("APP_HTTPPORT", "8080")
+
+	conf, err := config.NewConfig("json", "conf.json", config.WithEnv("APP"))
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db::module"); v != "orm" {
+		t.Errorf("db::module = %q, want orm", v)
+	}
+	if v, err := conf.Int("db::maxIdle"); err != nil || v != 10 {
+		t.Errorf("db::maxIdle = %d, %v, want 10", v, err)
+	}
+	if v, err := conf.Int("httpport"); err != nil || v != 8080 {
+		t.Errorf("httpport = %d, %v, want 8080", v, err)
+	}
+
+	t.Setenv("APP_HTTPPORT", "http")
+	if _, err := config.NewConfig("json", "conf.json", config.WithEnv("APP")); err == nil {
+		t.Errorf("expected error for non-numeric APP_HTTPPORT")
+	}
+}
+
+func TestJsonWatcher(t *testing.T) {
+	for _, opts := range [][]config.Option{nil, {config.WithPollInterval(20 * time.Millisecond)}} {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "conf.json")
+		if err := os.WriteFile(file, []byte(`{"runmode": "dev", "db": {"module": "bee"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		wd, _ := os.Getwd()
+		rel, err := filepath.Rel(wd, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w, err := config.NewWatcher("json", rel, opts...)
+		if err != nil {
+			t.Fatalf("NewWatcher error: %v", err)
+		}
+		defer w.Close()
+
+		changed := make(chan [2]interface{}, 4)
+		w.OnChange("runmode", func(old, new interface{}) {
+			changed <- [2]interface{}{old, new}
+		})
+		w.OnChange("db::module", func(old, new interface{}) {
+			t.Errorf("db::module did not change, got callback %v -> %v", old, new)
+		})
+
+		if err := os.WriteFile(file, []byte(`{"runmode": "prod", "db": {"module": "bee"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case c := <-changed:
+			if c[0] != "dev" || c[1] != "prod" {
+				t.Errorf("unexpected change %v -> %v", c[0], c[1])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no change notification")
+		}
+		if v := w.String("runmode"); v != "prod" {
+			t.Errorf("runmode = %q, want prod", v)
+		}
+		if err := w.Set("runmode", "test"); err == nil || w.String("runmode") != "prod" {
+			t.Errorf("Set on a watched config: %v, runmode = %q", err, w.String("runmode"))
+		}
+		if err := w.Delete("runmode"); err == nil {
+			t.Error("Delete on a watched config did not fail")
+		}
+	}
+}
+
+// TestJsonWatcherFiles changes an included file, and swaps the directory
+// holding the config the way Kubernetes updates a mounted ConfigMap.
+func TestJsonWatcherFiles(t *testing.T) {
+	for _, opts := range [][]config.Option{nil, {config.WithPollInterval(20 * time.Millisecond)}} {
+		dir := t.TempDir()
+		write := func(name, content string) {
+			t.Helper()
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		write("v1/conf.json", `{"include": ["conf.d/*.json"], "runmode": "dev"}`)
+		write("v1/conf.d/db.json", `{"db": {"maxIdle": 10}}`)
+		write("v2/conf.json", `{"include": ["conf.d/*.json"], "runmode": "prod"}`)
+		write("v2/conf.d/db.json", `{"db": {"maxIdle": 10}}`)
+		if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+		for _, name := range []string{"conf.json", "conf.d"} {
+			if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w, err := config.NewWatcher("json", filepath.Join(dir, "conf.json"), opts...)
+		if err != nil {
+			t.Fatalf("NewWatcher error: %v", err)
+		}
+		defer w.Close()
+		changed := make(chan string, 4)
+		w.OnChange("", func(old, new interface{}) {
+			changed <- fmt.Sprint(new)
+		})
+		wait := func(key, want string) {
+			t.Helper()
+			select {
+			case <-changed:
+			case <-time.After(3 * time.Second):
+				t.Fatalf("no change notification for %s", key)
+			}
+			if v := w.String(key); v != want {
+				t.Errorf("%s = %q, want %q", key, v, want)
+			}
+		}
+
+		write("v1/conf.d/db.json", `{"db": {"maxIdle": "20"}}`)
+		wait("db.maxIdle", "20")
+
+		// swap ..data atomically, as the kubelet does
+		if err := os.Symlink("v2", filepath.Join(dir, "..data_tmp")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+		wait("runmode", "prod")
+	}
+}
+
+func TestJsonKeyPath(t *testing.T) {
+	conf, err := config.NewConfig("json", "conf.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db.module"); v != "bee" {
+		t.Errorf("db.module = %q, want bee", v)
+	}
+	if v := conf.String("servers[1].host"); v != "10.0.0.2" {
+		t.Errorf("servers[1].host = %q, want 10.0.0.2", v)
+	}
+	if v, err := conf.Int("servers[0]::port"); err != nil || v != 8001 {
+		t.Errorf("servers[0]::port = %d, %v, want 8001", v, err)
+	}
+	var hosts []string
+	if err := conf.Unmarshal("servers[*].host", &hosts); err != nil || len(hosts) != 2 {
+		t.Errorf("servers[*].host = %v, %v", hosts, err)
+	}
+}
+
+func TestJsonSchema(t *testing.T) {
+	max := 100.0
+	schema := &config.Schema{
+		Fields: []config.Field{
+			{Key: "httpport", Type: "int", Required: true},
+			{Key: "runmode", Type: "string", Enum: []interface{}{"prod", "test"}},
+			{Key: "db::maxConn", Type: "int", Max: &max},
+			{Key: "db::link", Pattern: `^\w+:.*@tcp\(`},
+			{Key: "secret", Required: true},
+		},
+		Exclusive: [][]string{{"db::link", "db::dsn"}},
+	}
+
+	_, err := config.NewConfig("json", "conf.json", config.WithSchema(schema))
+	verr, ok := err.(*config.ValidationError)
+	if !ok {
+		t.Fatalf("expected *config.ValidationError, got %v", err)
+	}
+	if len(verr.Violations) != 3 {
+		t.Fatalf("expected 3 violations, got:\n%v", err)
+	}
+
+	want := []struct {
+		key  string
+		line int
+	}{{"runmode", 4}, {"db::maxConn", 9}, {"secret", 0}}
+	for i, w := range want {
+		v := verr.Violations[i]
+		if v.Key != w.key || v.Line != w.line {
+			t.Errorf("violation %d = %s, want key %s at line %d", i, v, w.key, w.line)
+		}
+	}
+}
+
+func TestJsonSetSave(t *testing.T) {
+	conf, err := config.NewConfig("json", "conf.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if err := conf.Set("db::maxConn", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Set("servers[2]", map[string]interface{}{"host": "10.0.0.3", "port": 8003}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Delete("runmode"); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "conf.json")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, file)
+	saved, err := config.NewConfig("json", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v, err := saved.Int("db::maxConn"); err != nil || v != 100 {
+		t.Errorf("db::maxConn = %d, %v", v, err)
+	}
+	if v, err := saved.Int("servers[2].port"); err != nil || v != 8003 {
+		t.Errorf("servers[2].port = %d, %v", v, err)
+	}
+	if v := saved.DefaultString("runmode", "none"); v != "none" {
+		t.Errorf("runmode = %q, should be deleted", v)
+	}
+}
+
+func TestJsonSecrets(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "s3cret")
+	t.Setenv("TEST_DB_MAXIDLE", "20")
+
+	file := filepath.Join(t.TempDir(), "conf.json")
+	src := `{"db": {"link": "root:${env:TEST_DB_PASSWORD}@tcp(localhost:3306)/bill", "maxIdle": "${env:TEST_DB_MAXIDLE}"}}`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, file)
+
+	conf, err := config.NewConfig("json", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db::link"); v != "root:s3cret@tcp(localhost:3306)/bill" {
+		t.Errorf("db::link = %q", v)
+	}
+	var db struct {
+		MaxIdle int `json:"maxIdle"`
+	}
+	if err := conf.Unmarshal("db", &db); err != nil || db.MaxIdle != 20 {
+		t.Errorf("Unmarshal db = %+v, %v", db, err)
+	}
+
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(file); !strings.Contains(string(b), "${env:TEST_DB_PASSWORD}") {
+		t.Errorf("saved file should keep the placeholder:\n%s", b)
+	}
+}
+
+func TestJsonTypedGetters(t *testing.T) {
+	conf, err := config.NewConfig("json", "conf.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v, err := conf.Duration("timeout"); err != nil || v != 90*time.Second {
+		t.Errorf("Duration(timeout) = %v, %v", v, err)
+	}
+	if v, err := conf.Bytes("maxsize"); err != nil || v != 16<<20 {
+		t.Errorf("Bytes(maxsize) = %d, %v", v, err)
+	}
+	if v, err := conf.Time("released"); err != nil || !v.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("Time(released) = %v, %v", v, err)
+	}
+	if v, err := conf.Uint64("httpport"); err != nil || v != 38080 {
+		t.Errorf("Uint64(httpport) = %d, %v", v, err)
+	}
+	if v := conf.Strings("tags"); !reflect.DeepEqual(v, []string{"api", "billing"}) {
+		t.Errorf("Strings(tags) = %v", v)
+	}
+	if v, err := conf.IntSlice("servers[*].port"); err != nil || !reflect.DeepEqual(v, []int{8001, 8002}) {
+		t.Errorf("IntSlice(servers[*].port) = %v, %v", v, err)
+	}
+	if v, err := conf.Float64Slice("weights"); err != nil || !reflect.DeepEqual(v, []float64{0.5, 1.5}) {
+		t.Errorf("Float64Slice(weights) = %v, %v", v, err)
+	}
+	if v, err := conf.StringMap("db"); err != nil || v["module"] != "bee" || v["maxConn"] != "300" {
+		t.Errorf("StringMap(db) = %v, %v", v, err)
+	}
+
+	db := conf.Sub("db")
+	if db == nil {
+		t.Fatal("Sub(db) = nil")
+	}
+	if v, err := db.Int("maxIdle"); err != nil || v != 50 {
+		t.Errorf("Sub(db).Int(maxIdle) = %d, %v", v, err)
+	}
+	if conf.Sub("appname") != nil {
+		t.Error("Sub(appname) is not nil")
+	}
+}
+
+func TestJsonInclude(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"conf.json": `{"include": "base.json", "db": {"maxConn": 500}}`,
+		"base.json": `{"db": {"module": "bee", "maxConn": 300}, "appname": "go-bill-server"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, filepath.Join(dir, "conf.json"))
+
+	conf, err := config.NewConfig("json", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("appname"); v != "go-bill-server" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, _ := conf.Int("db::maxConn"); v != 500 {
+		t.Errorf("db::maxConn = %d, want 500", v)
+	}
+	if v := conf.String("db::module"); v != "bee" {
+		t.Errorf("db::module = %q, want bee", v)
+	}
+
+	var composed *config.ComposedError
+	err = conf.SaveConfigFile(filepath.Join(dir, "saved.json"))
+	if !errors.As(err, &composed) || len(composed.Files) != 1 || filepath.Base(composed.Files[0]) != "base.json" {
+		t.Errorf("SaveConfigFile error = %v, want ComposedError for base.json", err)
+	}
+}
+
+func TestJsonSources(t *testing.T) {
+	const content = `{"include": "base.json", "db": {"module": "bee"}}`
+	fsys := fstest.MapFS{
+		"conf/app.json":  {Data: []byte(content)},
+		"conf/base.json": {Data: []byte(`{"db": {"maxConn": 300}}`)},
+	}
+	conf, err := config.NewConfigFromFS("json", fsys, "conf/app.json")
+	if err != nil {
+		t.Fatalf("NewConfigFromFS error: %v", err)
+	}
+	if v, _ := conf.Int("db::maxConn"); v != 300 || conf.String("db::module") != "bee" {
+		t.Errorf("NewConfigFromFS: db::maxConn = %d, db::module = %q", v, conf.String("db::module"))
+	}
+
+	conf, err = config.NewConfigFromReader("json", strings.NewReader(`{"appname": "bill"}`))
+	if err != nil {
+		t.Fatalf("NewConfigFromReader error: %v", err)
+	}
+	if v := conf.String("appname"); v != "bill" {
+		t.Errorf("NewConfigFromReader: appname = %q", v)
+	}
+	if _, err := config.NewConfigFromBytes("json", []byte(`{`)); err == nil {
+		t.Error("NewConfigFromBytes with invalid json succeeded")
+	}
+	if _, err := config.NewConfigFromBytes("xml", []byte(`{}`)); err == nil {
+		t.Error("NewConfigFromBytes with unknown adapter succeeded")
+	}
+
+	abs, _ := filepath.Abs("conf.json")
+	if conf, err := config.NewConfig("json", abs); err != nil || conf.String("appname") != "go-bill-server" {
+		t.Errorf("NewConfig with absolute path: %v", err)
+	}
+}
+
+func TestJsonDottedKeys(t *testing.T) {
+	data := []byte(`{"a.b": "top", "hosts": {"example.com": {"port": 443}}}`)
+	conf, err := config.NewConfigFromBytes("json", data)
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("a.b"); v != "top" {
+		t.Errorf("a.b = %q", v)
+	}
+	if v, err := conf.Int("hosts::example.com::port"); err != nil || v != 443 {
+		t.Errorf("hosts::example.com::port = %d, %v", v, err)
+	}
+	if v, err := conf.Int("hosts.example.com.port"); err != nil || v != 443 {
+		t.Errorf("hosts.example.com.port = %d, %v", v, err)
+	}
+}
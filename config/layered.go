@@ -0,0 +1,369 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layered is a Configer that resolves every key through a stack of named
+// layers. Layers are given from lowest to highest precedence, so in
+//
+//	NewLayered(
+//		config.NewLayer("defaults", defaults),
+//		config.NewLayer("local", local),
+//		dotenv.EnvLayer("APP"),
+//		config.NewLayer("flags", flags),
+//	)
+//
+// a key set by a flag wins over the same key in the environment, local and
+// defaults, and Source reports "flags" for it.
+type Layered struct {
+	layers []Layer
+}
+
+// Layer is a named source of a Layered config.
+type Layer struct {
+	Name   string
+	Config Configer
+	// FoldCase merges the sections of this layer with those of the lower
+	// layers ignoring the case of keys, so that DB_MAXIDLE from the
+	// environment overrides maxIdle of a file. Keys of other layers are
+	// matched exactly.
+	FoldCase bool
+}
+
+// NewLayer returns a layer named name over conf.
+func NewLayer(name string, conf Configer) Layer {
+	return Layer{Name: name, Config: conf}
+}
+
+// NewLayered returns a Configer looking up keys in layers, the last layer
+// taking precedence.
+func NewLayered(layers ...Layer) *Layered {
+	return &Layered{layers: layers}
+}
+
+// Source returns the name of the layer that supplies key, or false if no
+// layer has it.
+func (l *Layered) Source(key string) (string, bool) {
+	if i := l.source(key); i >= 0 {
+		return l.layers[i].Name, true
+	}
+	return "", false
+}
+
+func (l *Layered) source(key string) int {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if hasKey(l.layers[i].Config, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *Layered) lookup(key string) (Configer, error) {
+	if i := l.source(key); i >= 0 {
+		return l.layers[i].Config, nil
+	}
+	return nil, fmt.Errorf("not exist key %q", key)
+}
+
+func hasKey(conf Configer, key string) bool {
+	var v interface{}
+	return conf.Unmarshal(key, &v) == nil && v != nil
+}
+
+// String returns the string value for a given key.
+func (l *Layered) String(key string) string {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return ""
+	}
+	return conf.String(key)
+}
+
+// Strings returns the []string value for a given key.
+func (l *Layered) Strings(key string) []string {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return nil
+	}
+	return conf.Strings(key)
+}
+
+// Int returns the integer value for a given key.
+func (l *Layered) Int(key string) (int, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return conf.Int(key)
+}
+
+// Int64 returns the int64 value for a given key.
+func (l *Layered) Int64(key string) (int64, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return conf.Int64(key)
+}
+
+// Bool returns the boolean value for a given key.
+func (l *Layered) Bool(key string) (bool, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return false, err
+	}
+	return conf.Bool(key)
+}
+
+// Float returns the float value for a given key.
+func (l *Layered) Float(key string) (float64, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0.0, err
+	}
+	return conf.Float(key)
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (l *Layered) DefaultString(key string, defaultVal string) string {
+	if v := l.String(key); v != "" {
+		return v
+	}
+	return defaultVal
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (l *Layered) DefaultStrings(key string, defaultVal []string) []string {
+	if v := l.Strings(key); v != nil {
+		return v
+	}
+	return defaultVal
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (l *Layered) DefaultInt(key string, defaultVal int) int {
+	if v, err := l.Int(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (l *Layered) DefaultInt64(key string, defaultVal int64) int64 {
+	if v, err := l.Int64(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (l *Layered) DefaultBool(key string, defaultVal bool) bool {
+	if v, err := l.Bool(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (l *Layered) DefaultFloat(key string, defaultVal float64) float64 {
+	if v, err := l.Float(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (l *Layered) Uint64(key string) (uint64, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return conf.Uint64(key)
+}
+
+// Duration returns the time.Duration value for a given key.
+func (l *Layered) Duration(key string) (time.Duration, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return conf.Duration(key)
+}
+
+// Bytes returns the size in bytes for a given key.
+func (l *Layered) Bytes(key string) (int64, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return 0, err
+	}
+	return conf.Bytes(key)
+}
+
+// Time returns the time.Time value for a given key.
+func (l *Layered) Time(key string) (time.Time, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return conf.Time(key)
+}
+
+// StringMap returns the section at key, merged across all sources, with its
+// values as strings.
+func (l *Layered) StringMap(key string) (map[string]string, error) {
+	var m map[string]interface{}
+	if err := l.Unmarshal(key, &m); err != nil {
+		return nil, err
+	}
+	return ParseStringMap(m)
+}
+
+// IntSlice returns the []int value for a given key.
+func (l *Layered) IntSlice(key string) ([]int, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return conf.IntSlice(key)
+}
+
+// Float64Slice returns the []float64 value for a given key.
+func (l *Layered) Float64Slice(key string) ([]float64, error) {
+	conf, err := l.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return conf.Float64Slice(key)
+}
+
+// Sub returns the section at key as a Layered over the sections of every
+// layer that has it, nil if none does.
+func (l *Layered) Sub(key string) Configer {
+	var subs []Layer
+	for _, layer := range l.layers {
+		if sub := layer.Config.Sub(key); sub != nil {
+			layer.Config = sub
+			subs = append(subs, layer)
+		}
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	return NewLayered(subs...)
+}
+
+// Unmarshal decodes the section at key into out. Sections present in several
+// layers are deep-merged first, so a higher layer only needs to contain the
+// keys it overrides.
+func (l *Layered) Unmarshal(key string, out interface{}) error {
+	var merged interface{}
+	for _, layer := range l.layers {
+		var v interface{}
+		if err := layer.Config.Unmarshal(key, &v); err != nil || v == nil {
+			continue
+		}
+		merged = merge(merged, v, layer.FoldCase)
+	}
+	if merged == nil {
+		return fmt.Errorf("not exist key %q", key)
+	}
+	return Decode(merged, out)
+}
+
+// Set sets key in the highest layer, so it takes precedence over all
+// others.
+func (l *Layered) Set(key string, value interface{}) error {
+	if len(l.layers) == 0 {
+		return fmt.Errorf("config: no layers")
+	}
+	return l.layers[len(l.layers)-1].Config.Set(key, value)
+}
+
+// Delete removes key from every layer that has it.
+func (l *Layered) Delete(key string) error {
+	found := false
+	for _, layer := range l.layers {
+		if hasKey(layer.Config, key) {
+			if err := layer.Config.Delete(key); err != nil {
+				return err
+			}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("not exist key %q", key)
+	}
+	return nil
+}
+
+// SaveConfigFile saves the highest layer, the one Set writes to, into file.
+func (l *Layered) SaveConfigFile(filename string) error {
+	if len(l.layers) == 0 {
+		return fmt.Errorf("config: no layers")
+	}
+	return l.layers[len(l.layers)-1].Config.SaveConfigFile(filename)
+}
+
+// mergeValues deep-merges src over dst. Maps are merged key by key, any other
+// value in src replaces the one in dst. Neither argument is modified.
+func mergeValues(dst, src interface{}) interface{} {
+	return merge(dst, src, false)
+}
+
+// merge is mergeValues, matching the keys of src to those of dst ignoring
+// case if fold is set.
+func merge(dst, src interface{}, fold bool) interface{} {
+	srcMap, ok := toStringMap(src)
+	if !ok {
+		return src
+	}
+	dstMap, ok := toStringMap(dst)
+	if !ok {
+		return copyMap(srcMap)
+	}
+
+	res := copyMap(dstMap)
+	for k, v := range srcMap {
+		if fold {
+			// keep the spelling of dst, the one struct tags are written in
+			if old, ok := lookupKeyFold(res, k); ok {
+				k = old
+			}
+		}
+		res[k] = merge(res[k], v, fold)
+	}
+	return res
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+// lookupKeyFold finds the key of m equal to name, or else equal ignoring
+// case, so that MAXIDLE from the environment merges with maxIdle from a
+// file and a MaxIdle field decodes maxIdle.
+func lookupKeyFold(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	"github.com/fengfenghuo/go-common-lib/config/dotenv"
+	_ "github.com/fengfenghuo/go-common-lib/config/json"
+)
+
+func TestLayered(t *testing.T) {
+	defaults, err := config.NewConfig("json", "testdata/base.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	override, err := config.NewConfig("json", "testdata/override.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	conf := config.NewLayered(config.NewLayer("defaults", defaults), config.NewLayer("override", override))
+
+	if v, err := conf.Int("port"); err != nil || v != 8080 {
+		t.Errorf("port = %d, %v, want 8080", v, err)
+	}
+	if name, ok := conf.Source("port"); !ok || name != "override" {
+		t.Errorf("Source(port) = %q, %v, want override", name, ok)
+	}
+	if v := conf.String("host"); v != "localhost" {
+		t.Errorf("host = %q, want localhost", v)
+	}
+	if name, ok := conf.Source("host"); !ok || name != "defaults" {
+		t.Errorf("Source(host) = %q, %v, want defaults", name, ok)
+	}
+	if _, ok := conf.Source("missing"); ok {
+		t.Errorf("Source(missing) should not be found")
+	}
+	if v := conf.DefaultInt("missing", 3); v != 3 {
+		t.Errorf("missing = %d, want default", v)
+	}
+
+	var db struct {
+		Module  string `config:"module"`
+		MaxIdle int    `config:"maxIdle"`
+	}
+	if err := conf.Unmarshal("db", &db); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if db.Module != "bee" || db.MaxIdle != 50 {
+		t.Errorf("merged db = %+v", db)
+	}
+
+	if err := conf.Set("host", "example.com"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	if name, _ := conf.Source("host"); name != "override" || defaults.String("host") != "localhost" {
+		t.Errorf("Set should write to the highest source only")
+	}
+	if err := conf.Delete("db.maxIdle"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, ok := conf.Source("db.maxIdle"); ok {
+		t.Errorf("db.maxIdle should be deleted from every source")
+	}
+}
+
+func TestLayeredFlagsAndEnv(t *testing.T) {
+	t.Setenv("APP_DB_MAXIDLE", "20")
+
+	file, err := config.NewConfig("json", "testdata/base.json")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	fs.String("db.module", "", "")
+	fs.String("host", "", "")
+	if err := fs.Parse([]string{"-port=9090", "-db.module=orm"}); err != nil {
+		t.Fatal(err)
+	}
+	flags, err := config.NewFlagConfig(fs)
+	if err != nil {
+		t.Fatalf("NewFlagConfig error: %v", err)
+	}
+	defaults := config.NewMapConfig(map[string]interface{}{"timeout": "5s", "db": map[string]interface{}{"Module": "x"}})
+
+	conf := config.NewLayered(
+		config.NewLayer("defaults", defaults),
+		config.NewLayer("file", file),
+		dotenv.EnvLayer("APP"),
+		config.NewLayer("flags", flags),
+	)
+	for key, want := range map[string]string{"port": "flags", "db.module": "flags", "db.maxIdle": "env", "host": "file", "timeout": "defaults"} {
+		if name, _ := conf.Source(key); name != want {
+			t.Errorf("Source(%s) = %q, want %q", key, name, want)
+		}
+	}
+	if v, err := conf.Int("port"); err != nil || v != 9090 {
+		t.Errorf("port = %d, %v, want 9090", v, err)
+	}
+	if v, err := conf.Duration("timeout"); err != nil || v.Seconds() != 5 {
+		t.Errorf("timeout = %v, %v", v, err)
+	}
+
+	var db map[string]interface{}
+	if err := conf.Unmarshal("db", &db); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	// only the env layer merges ignoring case: Module of the defaults stays
+	// apart from module of the file, while DB_MAXIDLE overrides its maxIdle
+	want := map[string]interface{}{"module": "orm", "maxIdle": "20", "Module": "x"}
+	if len(db) != len(want) {
+		t.Fatalf("merged db = %v, want %v", db, want)
+	}
+	for k, v := range want {
+		if db[k] != v {
+			t.Errorf("merged db[%s] = %#v, want %#v", k, db[k], v)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// MapConfig is a Configer over values held in memory, such as defaults set
+// in code or command-line flags. Values are converted with the Parse
+// functions, so numbers may be given as strings.
+type MapConfig struct {
+	Data map[string]interface{}
+}
+
+// NewMapConfig returns a MapConfig over data, which it keeps and modifies.
+func NewMapConfig(data map[string]interface{}) *MapConfig {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return &MapConfig{Data: data}
+}
+
+// NewFlagConfig returns a MapConfig holding the flags of fs that were set on
+// the command line, as the strings they were given as. A flag named
+// db.maxIdle is stored as the key maxIdle of the section db, so it overrides
+// that key of a file in a Layered config.
+func NewFlagConfig(fs *flag.FlagSet) (*MapConfig, error) {
+	conf := NewMapConfig(nil)
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		if err == nil {
+			err = SetPath(conf.Data, f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Bool returns the boolean value for a given key.
+func (conf *MapConfig) Bool(key string) (bool, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return false, err
+	}
+	return ParseBool(v)
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (conf *MapConfig) DefaultBool(key string, defaultVal bool) bool {
+	if v, err := conf.Bool(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// Int returns the integer value for a given key.
+func (conf *MapConfig) Int(key string) (int, error) {
+	v, err := conf.Int64(key)
+	return int(v), err
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (conf *MapConfig) DefaultInt(key string, defaultVal int) int {
+	if v, err := conf.Int(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// Int64 returns the int64 value for a given key.
+func (conf *MapConfig) Int64(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return ParseInt64(v)
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (conf *MapConfig) DefaultInt64(key string, defaultVal int64) int64 {
+	if v, err := conf.Int64(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// Float returns the float value for a given key.
+func (conf *MapConfig) Float(key string) (float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0.0, err
+	}
+	return ParseFloat(v)
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (conf *MapConfig) DefaultFloat(key string, defaultVal float64) float64 {
+	if v, err := conf.Float(key); err == nil {
+		return v
+	}
+	return defaultVal
+}
+
+// String returns the string value for a given key.
+func (conf *MapConfig) String(key string) string {
+	if v, err := conf.getData(key); err == nil {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (conf *MapConfig) DefaultString(key string, defaultVal string) string {
+	if v := conf.String(key); v != "" {
+		return v
+	}
+	return defaultVal
+}
+
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
+func (conf *MapConfig) Strings(key string) []string {
+	val, err := conf.getData(key)
+	if err != nil {
+		return nil
+	}
+	v, err := ParseStrings(val)
+	if err != nil || len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (conf *MapConfig) DefaultStrings(key string, defaultVal []string) []string {
+	if v := conf.Strings(key); v != nil {
+		return v
+	}
+	return defaultVal
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (conf *MapConfig) Uint64(key string) (uint64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return ParseUint64(v)
+}
+
+// Duration returns the time.Duration value for a given key, see ParseDuration.
+func (conf *MapConfig) Duration(key string) (time.Duration, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return ParseDuration(v)
+}
+
+// Bytes returns the size in bytes for a given key, see ParseBytes.
+func (conf *MapConfig) Bytes(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return ParseBytes(v)
+}
+
+// Time returns the time.Time value for a given key, see ParseTime.
+func (conf *MapConfig) Time(key string) (time.Time, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ParseTime(v)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *MapConfig) StringMap(key string) (map[string]string, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStringMap(v)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *MapConfig) IntSlice(key string) ([]int, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return ParseIntSlice(v)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *MapConfig) Float64Slice(key string) ([]float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFloat64Slice(v)
+}
+
+// Sub returns the section at key as a MapConfig sharing its data, nil if key
+// is not a section.
+func (conf *MapConfig) Sub(key string) Configer {
+	v, err := Lookup(conf.Data, key)
+	if m, ok := toStringMap(v); ok && err == nil {
+		return &MapConfig{Data: m}
+	}
+	return nil
+}
+
+// Unmarshal decodes the section at key, or all values if key is empty, into
+// out.
+func (conf *MapConfig) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return Decode(data, out)
+	}
+	v, err := conf.getData(key)
+	if err != nil {
+		return err
+	}
+	return Decode(v, out)
+}
+
+// RawData returns the values.
+func (conf *MapConfig) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Set sets the value of key, creating missing sections.
+func (conf *MapConfig) Set(key string, value interface{}) error {
+	return SetPath(conf.Data, key, value)
+}
+
+// Delete removes key from the config.
+func (conf *MapConfig) Delete(key string) error {
+	return DeletePath(conf.Data, key)
+}
+
+// SaveConfigFile returns an error: a MapConfig has no file format.
+func (conf *MapConfig) SaveConfigFile(filename string) error {
+	return fmt.Errorf("config: cannot save in-memory values to %s", filename)
+}
+
+// getData resolves key with Lookup and the secrets in its value.
+func (conf *MapConfig) getData(key string) (interface{}, error) {
+	v, err := Lookup(conf.Data, key)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveValue(v)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// timeLayouts are tried in order by ParseTime.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// byteUnits are binary multiples: "16MB" and "16MiB" are both 16 << 20.
+var byteUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+}
+
+// ParseUint64 converts a raw config value to a uint64.
+func ParseUint64(val interface{}) (uint64, error) {
+	switch v := val.(type) {
+	case uint64:
+		return v, nil
+	case string:
+		return strconv.ParseUint(strings.TrimSpace(v), 0, 64)
+	}
+	i, err := ParseInt64(val)
+	if err != nil {
+		return 0, fmt.Errorf("not uint64 value")
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%d is negative", i)
+	}
+	return uint64(i), nil
+}
+
+// ParseDuration converts a raw config value to a time.Duration. Strings are
+// parsed with time.ParseDuration ("1m30s") and must have a unit. Numbers
+// other than 0, such as timeout: 30, are rejected rather than guessed as
+// nanoseconds or seconds; write "30s" instead.
+func ParseDuration(val interface{}) (time.Duration, error) {
+	switch v := val.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		return time.ParseDuration(strings.TrimSpace(v))
+	}
+	i, err := ParseInt64(val)
+	if err != nil {
+		return 0, fmt.Errorf("not duration value")
+	}
+	if i != 0 {
+		return 0, fmt.Errorf("duration %d has no unit, write it as \"%ds\" or \"%dms\"", i, i, i)
+	}
+	return 0, nil
+}
+
+// ParseBytes converts a size such as "16MB", "512KiB" or "1G" to a number
+// of bytes. Units are binary multiples and case-insensitive; plain numbers
+// are bytes.
+func ParseBytes(val interface{}) (int64, error) {
+	s, ok := val.(string)
+	if !ok {
+		return ParseInt64(val)
+	}
+
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	mult, ok := byteUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("parsing %q: invalid size", s)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q: invalid size", s)
+	}
+	return int64(f * float64(mult)), nil
+}
+
+// ParseTime converts a raw config value to a time.Time. Strings may be in
+// RFC 3339 format, "2006-01-02 15:04:05" or "2006-01-02"; numbers are Unix
+// seconds.
+func ParseTime(val interface{}) (time.Time, error) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("parsing %q: invalid time", s)
+	}
+	i, err := ParseInt64(val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not time value")
+	}
+	return time.Unix(i, 0), nil
+}
+
+// ParseStrings converts a raw list, or a string separated by ";", to a
+// []string.
+func ParseStrings(val interface{}) ([]string, error) {
+	list, ok := toList(val)
+	if !ok {
+		return nil, fmt.Errorf("not string slice value")
+	}
+	res := make([]string, len(list))
+	for i, v := range list {
+		if res[i], ok = toString(v); !ok {
+			return nil, fmt.Errorf("element %d: not string value", i)
+		}
+	}
+	return res, nil
+}
+
+// ParseIntSlice converts a raw list, or a string separated by ";", to a
+// []int.
+func ParseIntSlice(val interface{}) ([]int, error) {
+	list, ok := toList(val)
+	if !ok {
+		return nil, fmt.Errorf("not int slice value")
+	}
+	res := make([]int, len(list))
+	for i, v := range list {
+		n, err := ParseInt64(v)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %s", i, err.Error())
+		}
+		res[i] = int(n)
+	}
+	return res, nil
+}
+
+// ParseFloat64Slice converts a raw list, or a string separated by ";", to a
+// []float64.
+func ParseFloat64Slice(val interface{}) ([]float64, error) {
+	list, ok := toList(val)
+	if !ok {
+		return nil, fmt.Errorf("not float64 slice value")
+	}
+	res := make([]float64, len(list))
+	for i, v := range list {
+		f, err := ParseFloat(v)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %s", i, err.Error())
+		}
+		res[i] = f
+	}
+	return res, nil
+}
+
+// ParseStringMap converts a raw section to a map[string]string.
+func ParseStringMap(val interface{}) (map[string]string, error) {
+	m, ok := toStringMap(val)
+	if !ok {
+		return nil, fmt.Errorf("not map value")
+	}
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		s, ok := toString(v)
+		if !ok {
+			return nil, fmt.Errorf("key %q: not string value", k)
+		}
+		res[k] = s
+	}
+	return res, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want int64
+	}{
+		{"512", 512},
+		{"16MB", 16 << 20},
+		{"16mib", 16 << 20},
+		{"1.5K", 1536},
+		{"2 GB", 2 << 30},
+		{float64(1024), 1024},
+	}
+	for _, tt := range tests {
+		if got, err := ParseBytes(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseBytes(%#v) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "MB", "16XB", "1.2.3K"} {
+		if _, err := ParseBytes(in); err == nil {
+			t.Errorf("ParseBytes(%q) succeeded", in)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, in := range []interface{}{"2024-01-02", "2024-01-02 00:00:00", "2024-01-02T00:00:00Z", want.Unix()} {
+		if got, err := ParseTime(in); err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%#v) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Error("ParseTime(yesterday) succeeded")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, in := range []interface{}{"1m30s", " 90s ", 90 * time.Second} {
+		if got, err := ParseDuration(in); err != nil || got != 90*time.Second {
+			t.Errorf("ParseDuration(%#v) = %v, %v", in, got, err)
+		}
+	}
+	if got, err := ParseDuration(float64(0)); err != nil || got != 0 {
+		t.Errorf("ParseDuration(0) = %v, %v", got, err)
+	}
+	for _, in := range []interface{}{"30", 30, float64(30), "soon"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%#v) succeeded", in)
+		}
+	}
+}
+
+func TestParseSlices(t *testing.T) {
+	if v, err := ParseIntSlice("1;2;3"); err != nil || len(v) != 3 || v[2] != 3 {
+		t.Errorf("ParseIntSlice = %v, %v", v, err)
+	}
+	if _, err := ParseIntSlice([]interface{}{1.0, "x"}); err == nil {
+		t.Error("ParseIntSlice with a string element succeeded")
+	}
+	if v, err := ParseStrings([]interface{}{"a", 1.0, true}); err != nil || v[1] != "1" || v[2] != "true" {
+		t.Errorf("ParseStrings = %v, %v", v, err)
+	}
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathElem is one step of a key path: a map key, a list index, or a
+// wildcard matching every element of a map or list.
+type pathElem struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+	dotted   bool // separated from the previous key by "." rather than "::"
+}
+
+// Lookup resolves key in data, the parsed config of any adapter. Sections are
+// separated by "::" or ".", so db::module and db.module are the same key.
+// List elements are addressed by index, as in servers[2].host, and "*"
+// matches every element of a list or map: servers[*].host or db.* return a
+// []interface{} with one entry per match. A key containing dots, such as the
+// ini section [server.http] or the host "example.com", is matched as a whole
+// before the path is split at its dots: server.http.port and
+// hosts::example.com both work.
+func Lookup(data interface{}, key string) (interface{}, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key is empty")
+	}
+
+	elems, err := parsePath(key)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := resolvePath(data, elems)
+	if !ok {
+		return nil, fmt.Errorf("not exist key %q", key)
+	}
+	return v, nil
+}
+
+func parsePath(key string) ([]pathElem, error) {
+	var elems []pathElem
+	var segs []string
+	var dotted []bool
+	for _, part := range strings.Split(key, "::") {
+		for i, seg := range strings.Split(part, ".") {
+			segs = append(segs, seg)
+			dotted = append(dotted, i > 0)
+		}
+	}
+	for n, seg := range segs {
+		name, rest := seg, ""
+		if i := strings.IndexByte(seg, '['); i >= 0 {
+			name, rest = seg[:i], seg[i:]
+		}
+		if name == "" && rest == "" {
+			return nil, fmt.Errorf("invalid key %q: empty section", key)
+		}
+		if name != "" {
+			elems = append(elems, pathElem{key: name, wildcard: name == "*", dotted: dotted[n]})
+		}
+
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid key %q: bad index %q", key, rest)
+			}
+			idx := rest[1:end]
+			rest = rest[end+1:]
+			if idx == "*" {
+				elems = append(elems, pathElem{isIndex: true, wildcard: true})
+				continue
+			}
+			n, err := strconv.Atoi(idx)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid key %q: bad index %q", key, idx)
+			}
+			elems = append(elems, pathElem{index: n, isIndex: true})
+		}
+	}
+	return elems, nil
+}
+
+func resolvePath(cur interface{}, elems []pathElem) (interface{}, bool) {
+	for i, elem := range elems {
+		if elem.wildcard {
+			return resolveWildcard(cur, elem, elems[i+1:])
+		}
+
+		if elem.isIndex {
+			list, ok := cur.([]interface{})
+			if !ok || elem.index >= len(list) {
+				return nil, false
+			}
+			cur = list[elem.index]
+			continue
+		}
+
+		m, ok := toStringMap(cur)
+		if !ok {
+			return nil, false
+		}
+		keys := joinedKeys(elems[i:])
+		for j := len(keys) - 1; j > 0; j-- {
+			if next, ok := m[keys[j]]; ok {
+				if v, ok := resolvePath(next, elems[i+j+1:]); ok {
+					return v, true
+				}
+			}
+		}
+		if cur, ok = m[elem.key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// joinedKeys returns the map keys elems[0] can stand for: keys[j] joins the
+// first j+1 keys of elems that are separated by ".", so that keys with dots
+// can be matched as a whole.
+func joinedKeys(elems []pathElem) []string {
+	keys := []string{elems[0].key}
+	for n := 1; n < len(elems); n++ {
+		if !elems[n].dotted || elems[n].wildcard || elems[n].isIndex {
+			break
+		}
+		keys = append(keys, keys[n-1]+"."+elems[n].key)
+	}
+	return keys
+}
+
+// matchPath merges the keys of elems that name a key with dots of data, the
+// longest first as in resolvePath, for SetPath and DeletePath. The keys
+// missing from data are kept apart.
+func matchPath(cur interface{}, elems []pathElem) []pathElem {
+	var res []pathElem
+	for i := 0; i < len(elems); i++ {
+		elem := elems[i]
+		if elem.wildcard {
+			res = append(res, elem)
+			cur = nil
+			continue
+		}
+		if elem.isIndex {
+			res = append(res, elem)
+			if list, ok := cur.([]interface{}); ok && elem.index < len(list) {
+				cur = list[elem.index]
+			} else {
+				cur = nil
+			}
+			continue
+		}
+
+		m, _ := toStringMap(cur)
+		keys := joinedKeys(elems[i:])
+		n := 0
+		for j := len(keys) - 1; j > 0; j-- {
+			if _, ok := m[keys[j]]; ok {
+				n = j
+				break
+			}
+		}
+		res = append(res, pathElem{key: keys[n]})
+		cur = m[keys[n]]
+		i += n
+	}
+	return res
+}
+
+// resolveWildcard resolves rest below every element of cur. A map's
+// elements are visited in key order.
+func resolveWildcard(cur interface{}, elem pathElem, rest []pathElem) (interface{}, bool) {
+	var children []interface{}
+	if list, ok := cur.([]interface{}); ok {
+		children = list
+	} else if m, ok := toStringMap(cur); ok && !elem.isIndex {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			children = append(children, m[k])
+		}
+	} else {
+		return nil, false
+	}
+
+	res := make([]interface{}, 0, len(children))
+	for _, child := range children {
+		if v, ok := resolvePath(child, rest); ok {
+			res = append(res, v)
+		}
+	}
+	return res, true
+}
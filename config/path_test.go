@@ -0,0 +1,93 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func TestLookup(t *testing.T) {
+	data := map[string]interface{}{
+		"appname": "demo",
+		"db":      map[interface{}]interface{}{"module": "bee", "maxIdle": 50},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "10.0.0.1", "ports": []interface{}{80, 443}},
+			map[string]interface{}{"host": "10.0.0.2"},
+		},
+	}
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"appname", "demo"},
+		{"db::module", "bee"},
+		{"db.module", "bee"},
+		{"servers[1].host", "10.0.0.2"},
+		{"servers[0]::ports[1]", 443},
+		{"servers[*].host", []interface{}{"10.0.0.1", "10.0.0.2"}},
+		{"servers.*.host", []interface{}{"10.0.0.1", "10.0.0.2"}},
+		{"db.*", []interface{}{50, "bee"}},
+	}
+	for _, tt := range tests {
+		got, err := config.Lookup(data, tt.key)
+		if err != nil {
+			t.Errorf("Lookup(%q) error: %v", tt.key, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+
+	for _, key := range []string{"", "nothing", "appname.x", "servers[2]", "servers[x]", "servers[1", "db..module", "db[0]"} {
+		if _, err := config.Lookup(data, key); err == nil {
+			t.Errorf("Lookup(%q) should fail", key)
+		}
+	}
+}
+
+func TestLookupDottedKeys(t *testing.T) {
+	data := map[string]interface{}{
+		"a.b":         1,
+		"a":           map[string]interface{}{"c": 2},
+		"server.http": map[string]interface{}{"port": 8080},
+		"hosts":       map[string]interface{}{"example.com": map[string]interface{}{"ip": "1.2.3.4"}},
+	}
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"a.b", 1},
+		{"a.c", 2},
+		{"server.http.port", 8080},
+		{"server.http::port", 8080},
+		{"hosts::example.com", map[string]interface{}{"ip": "1.2.3.4"}},
+		{"hosts.example.com.ip", "1.2.3.4"},
+	}
+	for _, tt := range tests {
+		got, err := config.Lookup(data, tt.key)
+		if err != nil {
+			t.Errorf("Lookup(%q) error: %v", tt.key, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+
+	if err := config.SetPath(data, "server.http.port", 9090); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetPath(data, "x.y", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.DeletePath(data, "hosts::example.com"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"port": 9090}
+	if !reflect.DeepEqual(data["server.http"], want) || data["x"] == nil || len(data["hosts"].(map[string]interface{})) != 0 {
+		t.Errorf("data = %#v", data)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locator is implemented by Configers that know where each key is defined,
+// so errors such as schema violations can point at the file and line.
+type Locator interface {
+	Locate(key string) (file string, line int, ok bool)
+}
+
+// Positions records the line of every key of a config file. Adapters fill it
+// while parsing and use it to implement Locator.
+type Positions struct {
+	File  string
+	lines map[string]int
+}
+
+// NewPositions returns an empty Positions for file.
+func NewPositions(file string) *Positions {
+	return &Positions{File: file, lines: make(map[string]int)}
+}
+
+// Add records that key is defined at line. key accepts any syntax understood
+// by Lookup.
+func (p *Positions) Add(key string, line int) {
+	p.lines[canonicalKey(key)] = line
+}
+
+// Locate returns the file and line where key is defined.
+func (p *Positions) Locate(key string) (string, int, bool) {
+	if p == nil {
+		return "", 0, false
+	}
+	line, ok := p.lines[canonicalKey(key)]
+	return p.File, line, ok
+}
+
+// canonicalKey rewrites key so that equivalent paths such as db::module and
+// db.module compare equal.
+func canonicalKey(key string) string {
+	elems, err := parsePath(key)
+	if err != nil {
+		return key
+	}
+	var b strings.Builder
+	for _, elem := range elems {
+		switch {
+		case elem.isIndex && elem.wildcard:
+			b.WriteString("[*]")
+		case elem.isIndex:
+			fmt.Fprintf(&b, "[%d]", elem.index)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(elem.key)
+		}
+	}
+	return b.String()
+}
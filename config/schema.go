@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Schema describes the keys a service expects in its config. It can be
+// declared in Go or decoded from a config file with Unmarshal.
+type Schema struct {
+	Fields []Field `config:"fields"`
+	// Exclusive lists groups of keys of which at most one may be set.
+	Exclusive [][]string `config:"exclusive"`
+}
+
+// Field describes a single key. Every check but Required is skipped when
+// the key is missing.
+type Field struct {
+	Key      string `config:"key"`
+	Required bool   `config:"required"`
+	// Type is one of string, int, float, bool, duration, list or map.
+	Type string `config:"type"`
+	// Min and Max bound numbers and durations (in seconds), or the length
+	// of strings and lists.
+	Min *float64 `config:"min"`
+	Max *float64 `config:"max"`
+	// Enum lists the allowed values, compared by their string form.
+	Enum []interface{} `config:"enum"`
+	// Pattern is a regular expression the string form must match.
+	Pattern string `config:"pattern"`
+}
+
+// Violation is a single schema check that failed. File and Line are set
+// when the Configer implements Locator.
+type Violation struct {
+	Key     string
+	Message string
+	File    string
+	Line    int
+}
+
+func (v Violation) String() string {
+	if v.File != "" && v.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", v.File, v.Line, v.Key, v.Message)
+	}
+	return v.Key + ": " + v.Message
+}
+
+// ValidationError lists every violation found by Schema.Validate.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Violations)+1)
+	lines = append(lines, fmt.Sprintf("config: %d schema violation(s)", len(e.Violations)))
+	for _, v := range e.Violations {
+		lines = append(lines, "\t"+v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithSchema makes NewConfig validate the loaded config against s and
+// return a *ValidationError if it does not conform.
+func WithSchema(s *Schema) Option {
+	return func(o *options) {
+		o.schema = s
+	}
+}
+
+// Validate checks conf against the schema. It returns nil or a
+// *ValidationError listing all violations, not only the first one.
+func (s *Schema) Validate(conf Configer) error {
+	var violations []Violation
+	report := func(key, format string, args ...interface{}) {
+		v := Violation{Key: key, Message: fmt.Sprintf(format, args...)}
+		if l, ok := conf.(Locator); ok {
+			if file, line, ok := l.Locate(key); ok {
+				v.File, v.Line = file, line
+			}
+		}
+		violations = append(violations, v)
+	}
+
+	for _, f := range s.Fields {
+		var val interface{}
+		if err := conf.Unmarshal(f.Key, &val); err != nil || val == nil {
+			if f.Required {
+				report(f.Key, "required key is missing")
+			}
+			continue
+		}
+		for _, msg := range f.check(val) {
+			report(f.Key, "%s", msg)
+		}
+	}
+
+	for _, group := range s.Exclusive {
+		var set []string
+		for _, key := range group {
+			if hasKey(conf, key) {
+				set = append(set, key)
+			}
+		}
+		if len(set) > 1 {
+			report(set[0], "mutually exclusive with %s", strings.Join(set[1:], ", "))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// check returns a message for every rule val breaks.
+func (f *Field) check(val interface{}) []string {
+	var msgs []string
+
+	size, hasSize := 0.0, false
+	switch f.Type {
+	case "":
+	case "string":
+		s, ok := val.(string)
+		if !ok {
+			return []string{fmt.Sprintf("must be a string, got %T", val)}
+		}
+		size, hasSize = float64(len(s)), true
+	case "int":
+		i, err := ParseInt64(val)
+		if err != nil {
+			return []string{fmt.Sprintf("must be an int, got %#v", val)}
+		}
+		size, hasSize = float64(i), true
+	case "float":
+		fl, err := ParseFloat(val)
+		if err != nil {
+			return []string{fmt.Sprintf("must be a float, got %#v", val)}
+		}
+		size, hasSize = fl, true
+	case "bool":
+		if _, err := ParseBool(val); err != nil {
+			return []string{fmt.Sprintf("must be a bool, got %#v", val)}
+		}
+	case "duration":
+		d, err := ParseDuration(val)
+		if err != nil {
+			return []string{fmt.Sprintf("must be a duration, got %#v", val)}
+		}
+		size, hasSize = d.Seconds(), true
+	case "list":
+		l, ok := val.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("must be a list, got %T", val)}
+		}
+		size, hasSize = float64(len(l)), true
+	case "map":
+		if _, ok := toStringMap(val); !ok {
+			return []string{fmt.Sprintf("must be a map, got %T", val)}
+		}
+	default:
+		return []string{fmt.Sprintf("unknown schema type %q", f.Type)}
+	}
+
+	if hasSize && f.Min != nil && size < *f.Min {
+		msgs = append(msgs, fmt.Sprintf("must be at least %v, got %v", *f.Min, size))
+	}
+	if hasSize && f.Max != nil && size > *f.Max {
+		msgs = append(msgs, fmt.Sprintf("must be at most %v, got %v", *f.Max, size))
+	}
+
+	str, isScalar := toString(val)
+	if len(f.Enum) > 0 {
+		allowed := make([]string, len(f.Enum))
+		found := false
+		for i, e := range f.Enum {
+			allowed[i] = fmt.Sprint(e)
+			found = found || (isScalar && allowed[i] == str)
+		}
+		if !found {
+			msgs = append(msgs, fmt.Sprintf("must be one of [%s], got %#v", strings.Join(allowed, ", "), val))
+		}
+	}
+	if f.Pattern != "" {
+		re, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("invalid schema pattern %q: %s", f.Pattern, err.Error()))
+		} else if !isScalar || !re.MatchString(str) {
+			msgs = append(msgs, fmt.Sprintf("must match %q, got %#v", f.Pattern, val))
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,303 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretResolver resolves the reference of a ${scheme:ref} placeholder in a
+// config value to the secret it stands for.
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to the SecretResolver interface.
+type SecretResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref).
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	secretMu        sync.RWMutex
+	secretResolvers = map[string]SecretResolver{
+		"env":  SecretResolverFunc(resolveEnv),
+		"file": SecretResolverFunc(resolveFile),
+	}
+)
+
+// RegisterSecretResolver makes resolver available for ${scheme:...}
+// placeholders. The env and file schemes are built in; ${enc:...} needs a
+// resolver from NewAESResolver to be registered.
+// If RegisterSecretResolver is called twice with the same scheme or if
+// resolver is nil, it panics.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if resolver == nil {
+		panic("config: RegisterSecretResolver resolver is nil")
+	}
+	if _, ok := secretResolvers[scheme]; ok {
+		panic("config: RegisterSecretResolver called twice for scheme " + scheme)
+	}
+	secretResolvers[scheme] = resolver
+}
+
+// ResolveSecrets replaces every ${scheme:ref} placeholder in s by the secret
+// returned by the resolver registered for scheme. $${ is kept as a literal
+// ${, and ${...} without a scheme is left untouched.
+func ResolveSecrets(s string) (string, error) {
+	res, err := resolveSecrets(s)
+	if err != nil {
+		return "", fmt.Errorf("config: %s", err.Error())
+	}
+	return res, nil
+}
+
+func resolveSecrets(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			break
+		}
+		scheme, ref, ok := strings.Cut(s[i+2:i+end], ":")
+		if !ok {
+			b.WriteString(s[:i+end+1])
+			s = s[i+end+1:]
+			continue
+		}
+
+		secretMu.RLock()
+		resolver := secretResolvers[scheme]
+		secretMu.RUnlock()
+		if resolver == nil {
+			return "", fmt.Errorf("unknown secret scheme %q (forgotten RegisterSecretResolver?)", scheme)
+		}
+		val, err := resolver.Resolve(ref)
+		if err != nil {
+			return "", fmt.Errorf("resolve ${%s:...}: %s", scheme, err.Error())
+		}
+		b.WriteString(s[:i])
+		b.WriteString(val)
+		s = s[i+end+1:]
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+// ResolveValue returns a copy of the raw config value v with the secrets of
+// all strings in it resolved. v itself is not modified, so saving the config
+// never writes the secrets in plain text.
+func ResolveValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return ResolveSecrets(val)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(val))
+		for k, vv := range val {
+			r, err := ResolveValue(vv)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	case map[interface{}]interface{}:
+		res := make(map[interface{}]interface{}, len(val))
+		for k, vv := range val {
+			r, err := ResolveValue(vv)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = r
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, vv := range val {
+			r, err := ResolveValue(vv)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = r
+		}
+		return res, nil
+	}
+	return v, nil
+}
+
+// CheckSecrets resolves every secret placeholder in data and returns the
+// first that fails, with the key it was found at. NewConfig checks the
+// configs it loads, so that an unknown scheme, a missing variable or file or
+// a wrong key is reported when loading; the getters would return zero values
+// for it, which DefaultString and the other Default getters replace silently.
+func CheckSecrets(data map[string]interface{}) error {
+	return checkSecrets("", data)
+}
+
+func checkSecrets(path string, v interface{}) error {
+	switch val := v.(type) {
+	case string:
+		if _, err := resolveSecrets(val); err != nil {
+			return fmt.Errorf("config: %s: %s", path, err.Error())
+		}
+	case []interface{}:
+		for i, vv := range val {
+			if err := checkSecrets(fmt.Sprintf("%s[%d]", path, i), vv); err != nil {
+				return err
+			}
+		}
+	default:
+		m, ok := toStringMap(v)
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := checkSecrets(joinPath(path, k), m[k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IsSecret reports whether the raw string s contains a secret placeholder.
+func IsSecret(s string) bool {
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			return false
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return false
+		}
+		if (i == 0 || s[i-1] != '$') && strings.Contains(s[i:i+end], ":") {
+			return true
+		}
+		s = s[i+end+1:]
+	}
+}
+
+func resolveEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func resolveFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+type aesResolver struct {
+	aead cipher.AEAD
+}
+
+// NewAESResolver returns a resolver for ${enc:...} values encrypted with
+// EncryptSecret, using the AES key in keyFile. Register it with
+//
+//	config.RegisterSecretResolver("enc", resolver)
+func NewAESResolver(keyFile string) (SecretResolver, error) {
+	aead, err := newAEAD(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &aesResolver{aead: aead}, nil
+}
+
+func (r *aesResolver) Resolve(ref string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return "", err
+	}
+	size := r.aead.NonceSize()
+	if len(data) < size {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plain, err := r.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// EncryptSecret encrypts plaintext with AES-GCM using the key in keyFile and
+// returns the ${enc:...} placeholder to put into a config file.
+func EncryptSecret(keyFile, plaintext string) (string, error) {
+	aead, err := newAEAD(keyFile)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return "${enc:" + base64.StdEncoding.EncodeToString(data) + "}", nil
+}
+
+// newAEAD reads a 16, 24 or 32 byte AES key, stored raw, hex or base64
+// encoded, from keyFile.
+func newAEAD(keyFile string) (cipher.AEAD, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key := b
+	text := strings.TrimSpace(string(b))
+	if k, err := hex.DecodeString(text); err == nil && validKeySize(len(k)) {
+		key = k
+	} else if k, err := base64.StdEncoding.DecodeString(text); err == nil && validKeySize(len(k)) {
+		key = k
+	}
+	if !validKeySize(len(key)) {
+		return nil, fmt.Errorf("config: key file %s: key must be 16, 24 or 32 bytes", keyFile)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func validKeySize(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
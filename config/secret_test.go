@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+	_ "github.com/fengfenghuo/go-common-lib/config/json"
+	_ "github.com/fengfenghuo/go-common-lib/config/yaml"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "db")
+	if err := os.WriteFile(secretFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_DB_USER", "root")
+
+	tests := map[string]string{
+		"plain":               "plain",
+		"${env:TEST_DB_USER}": "root",
+		"${env:TEST_DB_USER}:${file:" + secretFile + "}@tcp": "root:s3cret@tcp",
+		"$${env:TEST_DB_USER}":                               "${env:TEST_DB_USER}",
+		"${HOME}":                                            "${HOME}",
+	}
+	for in, want := range tests {
+		got, err := config.ResolveSecrets(in)
+		if err != nil || got != want {
+			t.Errorf("ResolveSecrets(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"${env:TEST_NOT_SET_VARIABLE}", "${nosuch:x}", "${file:" + filepath.Join(dir, "missing") + "}"} {
+		if _, err := config.ResolveSecrets(in); err == nil {
+			t.Errorf("ResolveSecrets(%q) should fail", in)
+		}
+	}
+
+	_, err := config.NewConfigFromBytes("json", []byte(`{"db": {"hosts": ["a", "${env:TEST_NOT_SET_VARIABLE}"]}}`))
+	if err == nil || !strings.Contains(err.Error(), "db.hosts[1]") || !strings.Contains(err.Error(), "TEST_NOT_SET_VARIABLE") {
+		t.Errorf("NewConfigFromBytes error = %v, want the unresolved db.hosts[1]", err)
+	}
+
+	if !config.IsSecret("a${env:X}b") || config.IsSecret("$${env:X}") || config.IsSecret("${X}") {
+		t.Errorf("IsSecret misdetects placeholders")
+	}
+}
+
+func TestNumericSecrets(t *testing.T) {
+	t.Setenv("TEST_PORT", "8080")
+	t.Setenv("TEST_RATIO", "0.5")
+	for adapter, data := range map[string]string{
+		"json": `{"port": "${env:TEST_PORT}", "ratio": "${env:TEST_RATIO}", "max": 5}`,
+		"yaml": "port: ${env:TEST_PORT}\nratio: ${env:TEST_RATIO}\nmax: 5\n",
+	} {
+		conf, err := config.NewConfigFromBytes(adapter, []byte(data))
+		if err != nil {
+			t.Fatalf("%s: NewConfigFromBytes error: %v", adapter, err)
+		}
+		if v, err := conf.Int("port"); err != nil || v != 8080 {
+			t.Errorf("%s: Int(port) = %d, %v", adapter, v, err)
+		}
+		if v, err := conf.Int64("port"); err != nil || v != 8080 {
+			t.Errorf("%s: Int64(port) = %d, %v", adapter, v, err)
+		}
+		if v, err := conf.Float("ratio"); err != nil || v != 0.5 {
+			t.Errorf("%s: Float(ratio) = %v, %v", adapter, v, err)
+		}
+		if v, err := conf.Int64("max"); err != nil || v != 5 {
+			t.Errorf("%s: Int64(max) = %d, %v", adapter, v, err)
+		}
+	}
+}
+
+func TestAESResolver(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("000102030405060708090a0b0c0d0e0f\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := config.EncryptSecret(keyFile, "p@ssw0rd")
+	if err != nil {
+		t.Fatalf("EncryptSecret error: %v", err)
+	}
+	resolver, err := config.NewAESResolver(keyFile)
+	if err != nil {
+		t.Fatalf("NewAESResolver error: %v", err)
+	}
+	config.RegisterSecretResolver("testenc", resolver)
+
+	got, err := config.ResolveSecrets("${testenc:" + enc[len("${enc:"):])
+	if err != nil || got != "p@ssw0rd" {
+		t.Errorf("decrypted %q, %v", got, err)
+	}
+	if _, err := resolver.Resolve("bm90IGVuY3J5cHRlZA=="); err == nil {
+		t.Errorf("expected error for bad ciphertext")
+	}
+}
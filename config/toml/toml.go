@@ -0,0 +1,344 @@
+package toml
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func init() {
+	config.Register("toml", &Config{})
+}
+
+// Config is a toml config parser and implements Config interface.
+type Config struct{}
+
+// Parse returns a ConfigContainer with parsed toml config map.
+func (conf *Config) Parse(filename string) (config.Configer, error) {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return loadFromToml(file)
+}
+
+// ParseData returns a ConfigContainer with the toml config map parsed from data.
+func (conf *Config) ParseData(data []byte) (config.Configer, error) {
+	return loadFromToml(data)
+}
+
+func loadFromToml(file []byte) (*ConfigEngine, error) {
+	var conf ConfigEngine
+	if _, err := toml.Decode(string(file), &conf.Data); err != nil {
+		return nil, err
+	}
+	conf.Data = normalize(conf.Data).(map[string]interface{})
+	return &conf, nil
+}
+
+// normalize converts the arrays of tables, decoded as
+// []map[string]interface{}, to []interface{} like the other lists, so that
+// paths, Unmarshal and the tools handle them the same way in every adapter.
+func normalize(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, e := range vv {
+			vv[k] = normalize(e)
+		}
+		return vv
+	case []map[string]interface{}:
+		list := make([]interface{}, len(vv))
+		for i, e := range vv {
+			list[i] = normalize(e)
+		}
+		return list
+	case []interface{}:
+		for i, e := range vv {
+			vv[i] = normalize(e)
+		}
+		return vv
+	}
+	return v
+}
+
+// ConfigEngine ...
+type ConfigEngine struct {
+	Data    map[string]interface{}
+	overlay []string // environment variables overlaid on Data, see config.Overlayer
+}
+
+// Bool returns the boolean value for a given key.
+func (conf *ConfigEngine) Bool(key string) (bool, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return false, err
+	}
+	return config.ParseBool(v)
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (conf *ConfigEngine) DefaultBool(key string, defaultval bool) bool {
+	v, err := conf.Bool(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int returns the integer value for a given key.
+func (conf *ConfigEngine) Int(key string) (int, error) {
+	v, err := conf.Int64(key)
+	return int(v), err
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt(key string, defaultval int) int {
+	v, err := conf.Int(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Int64 returns the int64 value for a given key.
+func (conf *ConfigEngine) Int64(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseInt64(v)
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultInt64(key string, defaultval int64) int64 {
+	v, err := conf.Int64(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// Float returns the float value for a given key.
+func (conf *ConfigEngine) Float(key string) (float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0.0, err
+	}
+	return config.ParseFloat(v)
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultFloat(key string, defaultval float64) float64 {
+	v, err := conf.Float(key)
+	if err != nil {
+		return defaultval
+	}
+	return v
+}
+
+// String returns the string value for a given key.
+func (conf *ConfigEngine) String(key string) string {
+	if v, err := conf.getData(key); err == nil {
+		if vv, ok := v.(string); ok {
+			return vv
+		}
+	}
+	return ""
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultString(key string, defaultval string) string {
+	v := conf.String(key)
+	if v == "" {
+		return defaultval
+	}
+	return v
+}
+
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
+func (conf *ConfigEngine) Strings(key string) []string {
+	val, err := conf.getData(key)
+	if err != nil {
+		return nil
+	}
+	v, err := config.ParseStrings(val)
+	if err != nil || len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (conf *ConfigEngine) DefaultStrings(key string, defaultval []string) []string {
+	v := conf.Strings(key)
+	if v == nil {
+		return defaultval
+	}
+	return v
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (conf *ConfigEngine) Uint64(key string) (uint64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseUint64(v)
+}
+
+// Duration returns the time.Duration value, given as "1m30s", see config.ParseDuration for a given key.
+func (conf *ConfigEngine) Duration(key string) (time.Duration, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseDuration(v)
+}
+
+// Bytes returns the size in bytes, given as "16MB", "512KiB" or in bytes for a given key.
+func (conf *ConfigEngine) Bytes(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseBytes(v)
+}
+
+// Time returns the time.Time value, see config.ParseTime for a given key.
+func (conf *ConfigEngine) Time(key string) (time.Time, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.ParseTime(v)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *ConfigEngine) StringMap(key string) (map[string]string, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseStringMap(v)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) IntSlice(key string) ([]int, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseIntSlice(v)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) Float64Slice(key string) ([]float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseFloat64Slice(v)
+}
+
+// Sub returns the section at key as a Configer sharing its data, so Set on
+// it changes this config too. It returns nil if key is not a section.
+func (conf *ConfigEngine) Sub(key string) config.Configer {
+	v, err := config.Lookup(conf.Data, key)
+	if m, ok := v.(map[string]interface{}); ok && err == nil {
+		return &ConfigEngine{Data: m}
+	}
+	return nil
+}
+
+// Unmarshal decodes the section at key, or the whole config if key is empty,
+// into the struct pointed to by out.
+func (conf *ConfigEngine) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := config.ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return config.Decode(data, out)
+	}
+	v, err := conf.getData(key)
+	if err != nil {
+		return err
+	}
+	return config.Decode(v, out)
+}
+
+// RawData returns the parsed config map.
+func (conf *ConfigEngine) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Set sets the value of key, creating missing sections. The value is stored
+// the way the toml decoder returns it, so the getters treat it like parsed data.
+func (conf *ConfigEngine) Set(key string, value interface{}) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]interface{}{"v": value}); err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	if _, err := toml.Decode(buf.String(), &m); err != nil {
+		return err
+	}
+	if conf.Data == nil {
+		conf.Data = make(map[string]interface{})
+	}
+	return config.SetPath(conf.Data, key, normalize(m["v"]))
+}
+
+// Delete removes key from the config.
+func (conf *ConfigEngine) Delete(key string) error {
+	return config.DeletePath(conf.Data, key)
+}
+
+// SetOverlay records the environment variables overlaid on the config, see
+// config.Overlayer.
+func (conf *ConfigEngine) SetOverlay(vars []string) {
+	conf.overlay = vars
+}
+
+// Marshal returns the config in toml.
+func (conf *ConfigEngine) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(conf.Data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveConfigFile save the config into file
+// A config overlaid with environment variables cannot be saved, see
+// config.Overlayer.
+func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
+	if len(conf.overlay) > 0 {
+		return &config.OverlayError{Vars: conf.overlay}
+	}
+	b, err := conf.Marshal()
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(filename, b)
+}
+
+// getData resolves key with config.Lookup and the secrets in its value with
+// config.ResolveValue.
+func (conf *ConfigEngine) getData(key string) (interface{}, error) {
+	v, err := config.Lookup(conf.Data, key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ResolveValue(v)
+}
@@ -0,0 +1,107 @@
+package toml_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func TestTomlConfig(t *testing.T) {
+	conf, err := config.NewConfig("toml", "conf.toml")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v := conf.String("appname"); v != "go-bill-server" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, err := conf.Int("httpport"); err != nil || v != 38080 {
+		t.Errorf("httpport = %d, %v", v, err)
+	}
+	if v := conf.String("db::module"); v != "bee" {
+		t.Errorf("db::module = %q", v)
+	}
+	if v := conf.DefaultInt("db::maxIdle", 1); v != 50 {
+		t.Errorf("db::maxIdle = %d", v)
+	}
+	if v := conf.DefaultString("db::nothing", "x"); v != "x" {
+		t.Errorf("db::nothing = %q", v)
+	}
+	if _, err := conf.Int("appname::module"); err == nil {
+		t.Errorf("expected error for key below a value")
+	}
+
+	file := filepath.Join(t.TempDir(), "saved.toml")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	saved, err := config.NewConfig("toml", file)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := saved.String("db::link"); v != conf.String("db::link") {
+		t.Errorf("saved db::link = %q", v)
+	}
+}
+
+func TestTomlFromBytes(t *testing.T) {
+	conf, err := config.NewConfigFromBytes("toml", []byte("appname = \"bill\"\n[db]\nmaxIdle = 50\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("appname"); v != "bill" {
+		t.Errorf("appname = %q", v)
+	}
+	if v, err := conf.Int("db.maxIdle"); err != nil || v != 50 {
+		t.Errorf("db.maxIdle = %d, %v", v, err)
+	}
+}
+
+func TestTomlArrayOfTables(t *testing.T) {
+	data := []byte("[[servers]]\nhost = \"a\"\nport = 80\n\n[[servers]]\nhost = \"b\"\nport = 81\n")
+	conf, err := config.NewConfigFromBytes("toml", data)
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("servers[1].host"); v != "b" {
+		t.Errorf("servers[1].host = %q", v)
+	}
+	if v := conf.Strings("servers[*].host"); len(v) != 2 || v[0] != "a" || v[1] != "b" {
+		t.Errorf("servers[*].host = %v", v)
+	}
+
+	var servers []struct {
+		Host string
+		Port int
+	}
+	if err := conf.Unmarshal("servers", &servers); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if len(servers) != 2 || servers[1].Host != "b" || servers[1].Port != 81 {
+		t.Errorf("servers = %+v", servers)
+	}
+
+	file := filepath.Join(t.TempDir(), "servers.toml")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+	saved, err := config.NewConfig("toml", file)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v, err := saved.Int("servers[0].port"); err != nil || v != 80 {
+		t.Errorf("saved servers[0].port = %d, %v", v, err)
+	}
+}
+
+func TestTomlIncludeKey(t *testing.T) {
+	// only json and yaml files are composed, include is an ordinary toml key
+	conf, err := config.NewConfigFromBytes("toml", []byte("include = \"missing.toml\"\nappname = \"bill\"\n"))
+	if err != nil {
+		t.Fatalf("NewConfigFromBytes error: %v", err)
+	}
+	if v := conf.String("include"); v != "missing.toml" {
+		t.Errorf("include = %q", v)
+	}
+}
@@ -0,0 +1,414 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultPollInterval is used when filesystem notifications are unavailable.
+const defaultPollInterval = 2 * time.Second
+
+// WithPollInterval makes a Watcher poll the file for changes at the given
+// interval instead of using filesystem notifications.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
+
+type changeCallback struct {
+	key string
+	fn  func(old, new interface{})
+}
+
+type configHolder struct {
+	conf Configer
+}
+
+// Watcher is a Configer that reloads its file whenever it changes on disk.
+// The parsed config is swapped atomically, so readers always see either the
+// old or the new file, never a mix of both.
+type Watcher struct {
+	adapter  string
+	path     string
+	opts     options
+	current  atomic.Value // configHolder
+	statMu   sync.Mutex
+	stats    map[string]os.FileInfo // watched files, nil if missing
+	dirs     map[string]bool        // directories added to notify
+	reloadMu sync.Mutex
+	mu       sync.Mutex
+	onChange []changeCallback
+	onError  []func(err error)
+	notify   *fsnotify.Watcher
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewWatcher loads the config like NewConfig and starts watching the file,
+// and the files it includes (see Includer). Changes are picked up through
+// inotify (or the platform equivalent), falling back to polling if
+// notifications are not available.
+func NewWatcher(adapterName, filePath string, opts ...Option) (*Watcher, error) {
+	o := newOptions(opts)
+	path, err := configPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := load(adapterName, path, o)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		adapter: adapterName,
+		path:    path,
+		opts:    o,
+		stats:   make(map[string]os.FileInfo),
+		dirs:    make(map[string]bool),
+		done:    make(chan struct{}),
+	}
+	w.current.Store(configHolder{conf})
+
+	if o.pollInterval <= 0 {
+		if w.notify, err = newFileNotify(path); err == nil {
+			w.dirs[filepath.Dir(path)] = true
+			w.track(conf)
+			go w.watchNotify()
+			return w, nil
+		}
+		o.pollInterval = defaultPollInterval
+	}
+	w.track(conf)
+	go w.watchPoll(o.pollInterval)
+	return w, nil
+}
+
+func newFileNotify(path string) (*fsnotify.Watcher, error) {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the directory so that editors replacing the file are noticed
+	if err := notify.Add(filepath.Dir(path)); err != nil {
+		notify.Close()
+		return nil, err
+	}
+	return notify, nil
+}
+
+// track records the state of the config file and of the files included into
+// conf, and watches the directories of the included files. Files no longer
+// included are forgotten.
+func (w *Watcher) track(conf Configer) {
+	files := []string{w.path}
+	if ic, ok := conf.(Includer); ok {
+		files = append(files, ic.Includes()...)
+	}
+
+	w.statMu.Lock()
+	defer w.statMu.Unlock()
+	stats := make(map[string]os.FileInfo, len(files))
+	for _, f := range files {
+		if fi, ok := w.stats[f]; ok {
+			stats[f] = fi
+			continue
+		}
+		fi, _ := os.Stat(f)
+		stats[f] = fi
+
+		dir := filepath.Dir(f)
+		if w.notify == nil || w.dirs[dir] {
+			continue
+		}
+		if err := w.notify.Add(dir); err != nil {
+			w.reportError(fmt.Errorf("watch %s: %s", f, err.Error()))
+			continue
+		}
+		w.dirs[dir] = true
+	}
+	w.stats = stats
+}
+
+// OnChange registers fn to be called after a reload in which the value of
+// key changed. An empty key matches any change. old or new is nil when the
+// key did not exist before or after the reload.
+func (w *Watcher) OnChange(key string, fn func(old, new interface{})) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, changeCallback{key: key, fn: fn})
+}
+
+// OnError registers fn to be called when the changed file cannot be loaded,
+// or when watching it fails. The previous config stays in use.
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Reload parses the file again and notifies the callbacks of changed keys.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	conf, err := load(w.adapter, w.path, w.opts)
+	if err != nil {
+		w.reportError(err)
+		return err
+	}
+
+	old := w.Configer()
+	w.current.Store(configHolder{conf})
+	w.track(conf)
+
+	w.mu.Lock()
+	callbacks := append([]changeCallback{}, w.onChange...)
+	w.mu.Unlock()
+	for _, cb := range callbacks {
+		oldVal, newVal := rawValue(old, cb.key), rawValue(conf, cb.key)
+		if !reflect.DeepEqual(oldVal, newVal) {
+			cb.fn(oldVal, newVal)
+		}
+	}
+	return nil
+}
+
+// reportError calls the OnError callbacks with err.
+func (w *Watcher) reportError(err error) {
+	w.mu.Lock()
+	callbacks := append([]func(error){}, w.onError...)
+	w.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(err)
+	}
+}
+
+// Close stops watching the file.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		if w.notify != nil {
+			err = w.notify.Close()
+		}
+	})
+	return err
+}
+
+// Configer returns the currently loaded config.
+func (w *Watcher) Configer() Configer {
+	return w.current.Load().(configHolder).conf
+}
+
+func (w *Watcher) watchNotify() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// editors often write in several steps, wait for them to settle.
+			// The event may be for another file of the directory, such as
+			// the ..data symlink of a Kubernetes ConfigMap, so compare the
+			// watched files rather than the name of the event.
+			time.Sleep(50 * time.Millisecond)
+			if w.statChanged() {
+				w.Reload()
+			}
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			w.reportError(fmt.Errorf("watch %s: %s", w.path, err.Error()))
+		}
+	}
+}
+
+func (w *Watcher) watchPoll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			if w.statChanged() {
+				w.Reload()
+			}
+		}
+	}
+}
+
+// statChanged reports whether one of the watched files was replaced, or its
+// size or modification time differs, since the last time it was seen. Stat
+// follows symlinks, so a symlink swapped to another file counts as a change.
+// Files missing at the moment are skipped.
+func (w *Watcher) statChanged() bool {
+	w.statMu.Lock()
+	defer w.statMu.Unlock()
+	changed := false
+	for f, old := range w.stats {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if old != nil && os.SameFile(old, fi) && fi.ModTime().Equal(old.ModTime()) && fi.Size() == old.Size() {
+			continue
+		}
+		w.stats[f] = fi
+		changed = true
+	}
+	return changed
+}
+
+// rawValue returns the raw value of key, or nil if it does not exist.
+func rawValue(conf Configer, key string) interface{} {
+	var v interface{}
+	if err := conf.Unmarshal(key, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// String returns the string value for a given key.
+func (w *Watcher) String(key string) string {
+	return w.Configer().String(key)
+}
+
+// Strings returns the []string value for a given key.
+func (w *Watcher) Strings(key string) []string {
+	return w.Configer().Strings(key)
+}
+
+// Int returns the integer value for a given key.
+func (w *Watcher) Int(key string) (int, error) {
+	return w.Configer().Int(key)
+}
+
+// Int64 returns the int64 value for a given key.
+func (w *Watcher) Int64(key string) (int64, error) {
+	return w.Configer().Int64(key)
+}
+
+// Bool returns the boolean value for a given key.
+func (w *Watcher) Bool(key string) (bool, error) {
+	return w.Configer().Bool(key)
+}
+
+// Float returns the float value for a given key.
+func (w *Watcher) Float(key string) (float64, error) {
+	return w.Configer().Float(key)
+}
+
+// DefaultString returns the string value for a given key.
+// if err != nil return defaultval
+func (w *Watcher) DefaultString(key string, defaultVal string) string {
+	return w.Configer().DefaultString(key, defaultVal)
+}
+
+// DefaultStrings returns the []string value for a given key.
+// if err != nil return defaultval
+func (w *Watcher) DefaultStrings(key string, defaultVal []string) []string {
+	return w.Configer().DefaultStrings(key, defaultVal)
+}
+
+// DefaultInt returns the integer value for a given key.
+// if err != nil return defaultval
+func (w *Watcher) DefaultInt(key string, defaultVal int) int {
+	return w.Configer().DefaultInt(key, defaultVal)
+}
+
+// DefaultInt64 returns the int64 value for a given key.
+// if err != nil return defaultval
+func (w *Watcher) DefaultInt64(key string, defaultVal int64) int64 {
+	return w.Configer().DefaultInt64(key, defaultVal)
+}
+
+// DefaultBool return the bool value if has no error
+// otherwise return the defaultval
+func (w *Watcher) DefaultBool(key string, defaultVal bool) bool {
+	return w.Configer().DefaultBool(key, defaultVal)
+}
+
+// DefaultFloat returns the float64 value for a given key.
+// if err != nil return defaultval
+func (w *Watcher) DefaultFloat(key string, defaultVal float64) float64 {
+	return w.Configer().DefaultFloat(key, defaultVal)
+}
+
+// Uint64 returns the uint64 value for a given key.
+func (w *Watcher) Uint64(key string) (uint64, error) {
+	return w.Configer().Uint64(key)
+}
+
+// Duration returns the time.Duration value for a given key.
+func (w *Watcher) Duration(key string) (time.Duration, error) {
+	return w.Configer().Duration(key)
+}
+
+// Bytes returns the size in bytes for a given key.
+func (w *Watcher) Bytes(key string) (int64, error) {
+	return w.Configer().Bytes(key)
+}
+
+// Time returns the time.Time value for a given key.
+func (w *Watcher) Time(key string) (time.Time, error) {
+	return w.Configer().Time(key)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (w *Watcher) StringMap(key string) (map[string]string, error) {
+	return w.Configer().StringMap(key)
+}
+
+// IntSlice returns the []int value for a given key.
+func (w *Watcher) IntSlice(key string) ([]int, error) {
+	return w.Configer().IntSlice(key)
+}
+
+// Float64Slice returns the []float64 value for a given key.
+func (w *Watcher) Float64Slice(key string) ([]float64, error) {
+	return w.Configer().Float64Slice(key)
+}
+
+// Sub returns the section at key of the current config. It is not updated
+// by later reloads.
+func (w *Watcher) Sub(key string) Configer {
+	return w.Configer().Sub(key)
+}
+
+// Unmarshal decodes the section at key of the current config into out.
+func (w *Watcher) Unmarshal(key string, out interface{}) error {
+	return w.Configer().Unmarshal(key, out)
+}
+
+// Set returns an error: the config of a Watcher is shared by concurrent
+// readers and replaced by every reload. Change the watched file instead, the
+// change is picked up by the next reload.
+func (w *Watcher) Set(key string, value interface{}) error {
+	return fmt.Errorf("config: cannot set %q on a watched config, change %s instead", key, w.path)
+}
+
+// Delete returns an error, see Set.
+func (w *Watcher) Delete(key string) error {
+	return fmt.Errorf("config: cannot delete %q on a watched config, change %s instead", key, w.path)
+}
+
+// SaveConfigFile saves the current config into file.
+func (w *Watcher) SaveConfigFile(filename string) error {
+	return w.Configer().SaveConfigFile(filename)
+}
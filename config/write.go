@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetPath sets key in data to value, creating the missing sections on the
+// way. key uses the syntax of Lookup without wildcards; a list index equal
+// to the length of the list appends to it.
+func SetPath(data map[string]interface{}, key string, value interface{}) error {
+	elems, err := parsePath(key)
+	if err != nil {
+		return err
+	}
+	_, err = setIn(data, matchPath(data, elems), value, key)
+	return err
+}
+
+func setIn(cur interface{}, elems []pathElem, value interface{}, key string) (interface{}, error) {
+	if len(elems) == 0 {
+		return value, nil
+	}
+
+	elem := elems[0]
+	if elem.wildcard {
+		return nil, fmt.Errorf("invalid key %q: wildcards cannot be set", key)
+	}
+
+	if elem.isIndex {
+		list, ok := cur.([]interface{})
+		if !ok && cur != nil {
+			return nil, fmt.Errorf("invalid key %q: not a list", key)
+		}
+		if elem.index > len(list) {
+			return nil, fmt.Errorf("invalid key %q: index %d out of range", key, elem.index)
+		}
+		var child interface{}
+		if elem.index < len(list) {
+			child = list[elem.index]
+		}
+		v, err := setIn(child, elems[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		if elem.index == len(list) {
+			return append(list, v), nil
+		}
+		list[elem.index] = v
+		return list, nil
+	}
+
+	switch m := cur.(type) {
+	case nil:
+		v, err := setIn(nil, elems[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{elem.key: v}, nil
+	case map[string]interface{}:
+		v, err := setIn(m[elem.key], elems[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		m[elem.key] = v
+		return m, nil
+	case map[interface{}]interface{}:
+		v, err := setIn(m[elem.key], elems[1:], value, key)
+		if err != nil {
+			return nil, err
+		}
+		m[elem.key] = v
+		return m, nil
+	}
+	return nil, fmt.Errorf("invalid key %q: %q is not a section", key, elem.key)
+}
+
+// DeletePath removes key from data. Removing a list element shifts the
+// following elements down.
+func DeletePath(data map[string]interface{}, key string) error {
+	elems, err := parsePath(key)
+	if err != nil {
+		return err
+	}
+	elems = matchPath(data, elems)
+	last := elems[len(elems)-1]
+	if last.wildcard {
+		return fmt.Errorf("invalid key %q: wildcards cannot be deleted", key)
+	}
+
+	var parent interface{} = data
+	if len(elems) > 1 {
+		var ok bool
+		if parent, ok = resolvePath(data, elems[:len(elems)-1]); !ok {
+			return fmt.Errorf("not exist key %q", key)
+		}
+	}
+
+	if last.isIndex {
+		list, ok := parent.([]interface{})
+		if !ok || last.index >= len(list) {
+			return fmt.Errorf("not exist key %q", key)
+		}
+		list = append(list[:last.index], list[last.index+1:]...)
+		_, err := setIn(data, elems[:len(elems)-1], list, key)
+		return err
+	}
+
+	switch m := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := m[last.key]; ok {
+			delete(m, last.key)
+			return nil
+		}
+	case map[interface{}]interface{}:
+		if _, ok := m[last.key]; ok {
+			delete(m, last.key)
+			return nil
+		}
+	}
+	return fmt.Errorf("not exist key %q", key)
+}
+
+// WriteFile writes data to filename atomically: the content goes to a
+// temporary file in the same directory that is then renamed over filename,
+// so readers and a Watcher never see a partially written config.
+func WriteFile(filename string, data []byte) (err error) {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(filename); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
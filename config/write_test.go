@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fengfenghuo/go-common-lib/config"
+)
+
+func TestSetDeletePath(t *testing.T) {
+	data := map[string]interface{}{
+		"db":      map[interface{}]interface{}{"module": "bee"},
+		"servers": []interface{}{"a", "b"},
+	}
+
+	for key, val := range map[string]interface{}{
+		"db::module":    "orm",
+		"db.pool.size":  10,
+		"servers[1]":    "c",
+		"servers[2]":    "d",
+		"cache[0].host": "localhost",
+	} {
+		if err := config.SetPath(data, key, val); err != nil {
+			t.Fatalf("SetPath(%q) error: %v", key, err)
+		}
+		if got, err := config.Lookup(data, key); err != nil || got != val {
+			t.Errorf("after SetPath(%q) got %v, %v", key, got, err)
+		}
+	}
+	for _, key := range []string{"servers[5]", "db.module.x", "servers[*]"} {
+		if err := config.SetPath(data, key, 1); err == nil {
+			t.Errorf("SetPath(%q) should fail", key)
+		}
+	}
+
+	if err := config.DeletePath(data, "servers[0]"); err != nil {
+		t.Fatalf("DeletePath error: %v", err)
+	}
+	if got, _ := config.Lookup(data, "servers"); !reflect.DeepEqual(got, []interface{}{"c", "d"}) {
+		t.Errorf("servers = %v after delete", got)
+	}
+	if err := config.DeletePath(data, "db.module"); err != nil {
+		t.Fatalf("DeletePath error: %v", err)
+	}
+	if _, err := config.Lookup(data, "db.module"); err == nil {
+		t.Errorf("db.module should be deleted")
+	}
+	if err := config.DeletePath(data, "db.module"); err == nil {
+		t.Errorf("deleting a missing key should fail")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "conf.json")
+	if err := os.WriteFile(file, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.WriteFile(file, []byte(`{"a": 1}`)); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil || string(b) != `{"a": 1}` {
+		t.Errorf("content = %q, %v", b, err)
+	}
+	if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("mode not preserved: %v, %v", fi.Mode(), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
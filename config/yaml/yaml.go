@@ -1,13 +1,15 @@
 package yaml
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
-	"os"
-	"strings"
+	"reflect"
+	"sort"
+	"time"
 
 	"github.com/fengfenghuo/go-common-lib/config"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 func init() {
@@ -19,27 +21,63 @@ type Config struct{}
 
 // Parse returns a ConfigContainer with parsed yaml config map.
 func (yaml *Config) Parse(filename string) (y config.Configer, err error) {
-	y, err = loadFromYaml(filename)
-	return
-}
-
-func loadFromYaml(filename string) (*ConfigEngine, error) {
 	file, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	return loadFromYaml(filename, file)
+}
 
-	var conf ConfigEngine
-	err = yaml.Unmarshal(file, &conf.Data)
-	if err != nil {
+// ParseData returns a ConfigContainer with the yaml config map parsed from data.
+func (yaml *Config) ParseData(data []byte) (config.Configer, error) {
+	return loadFromYaml("", data)
+}
+
+func loadFromYaml(filename string, file []byte) (*ConfigEngine, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(file, &node); err != nil {
+		return nil, err
+	}
+	conf := ConfigEngine{node: &node, positions: config.NewPositions(filename)}
+	if err := node.Decode(&conf.Data); err != nil {
 		return nil, err
 	}
+	addPositions(conf.positions, "", &node)
 	return &conf, nil
 }
 
+// addPositions records the line of every key below node.
+func addPositions(pos *config.Positions, path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			addPositions(pos, path, n)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			pos.Add(key, node.Content[i].Line)
+			addPositions(pos, key, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			key := fmt.Sprintf("%s[%d]", path, i)
+			pos.Add(key, n.Line)
+			addPositions(pos, key, n)
+		}
+	}
+}
+
 // ConfigEngine ...
 type ConfigEngine struct {
-	Data map[string]interface{}
+	Data      map[string]interface{}
+	node      *yaml.Node // parsed document, keeps comments and key order for saving
+	positions *config.Positions
+	includes  []string // files composed into Data, see config.Includer
+	overlay   []string // environment variables overlaid on Data, see config.Overlayer
 }
 
 // Bool returns the boolean value for a given key.
@@ -63,14 +101,8 @@ func (conf *ConfigEngine) DefaultBool(key string, defaultval bool) bool {
 
 // Int returns the integer value for a given key.
 func (conf *ConfigEngine) Int(key string) (int, error) {
-	if v, err := conf.getData(key); err != nil {
-		return 0, err
-	} else if vv, ok := v.(int); ok {
-		return vv, nil
-	} else if vv, ok := v.(int64); ok {
-		return int(vv), nil
-	}
-	return 0, fmt.Errorf("not int value")
+	v, err := conf.Int64(key)
+	return int(v), err
 }
 
 // DefaultInt returns the integer value for a given key.
@@ -85,12 +117,11 @@ func (conf *ConfigEngine) DefaultInt(key string, defaultval int) int {
 
 // Int64 returns the int64 value for a given key.
 func (conf *ConfigEngine) Int64(key string) (int64, error) {
-	if v, err := conf.getData(key); err != nil {
+	v, err := conf.getData(key)
+	if err != nil {
 		return 0, err
-	} else if vv, ok := v.(int64); ok {
-		return vv, nil
 	}
-	return 0, fmt.Errorf("not bool value")
+	return config.ParseInt64(v)
 }
 
 // DefaultInt64 returns the int64 value for a given key.
@@ -105,16 +136,11 @@ func (conf *ConfigEngine) DefaultInt64(key string, defaultval int64) int64 {
 
 // Float returns the float value for a given key.
 func (conf *ConfigEngine) Float(key string) (float64, error) {
-	if v, err := conf.getData(key); err != nil {
+	v, err := conf.getData(key)
+	if err != nil {
 		return 0.0, err
-	} else if vv, ok := v.(float64); ok {
-		return vv, nil
-	} else if vv, ok := v.(int); ok {
-		return float64(vv), nil
-	} else if vv, ok := v.(int64); ok {
-		return float64(vv), nil
 	}
-	return 0.0, fmt.Errorf("not float64 value")
+	return config.ParseFloat(v)
 }
 
 // DefaultFloat returns the float64 value for a given key.
@@ -147,13 +173,18 @@ func (conf *ConfigEngine) DefaultString(key string, defaultval string) string {
 	return v
 }
 
-// Strings returns the []string value for a given key.
+// Strings returns the []string value for a given key, a list or a ";"
+// separated string.
 func (conf *ConfigEngine) Strings(key string) []string {
-	v := conf.String(key)
-	if v == "" {
+	val, err := conf.getData(key)
+	if err != nil {
+		return nil
+	}
+	v, err := config.ParseStrings(val)
+	if err != nil || len(v) == 0 {
 		return nil
 	}
-	return strings.Split(v, ";")
+	return v
 }
 
 // DefaultStrings returns the []string value for a given key.
@@ -166,57 +197,271 @@ func (conf *ConfigEngine) DefaultStrings(key string, defaultval []string) []stri
 	return v
 }
 
-// SaveConfigFile save the config into file
-func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
-	// Write configuration file by filename.
-	f, err := os.Create(filename)
+// Uint64 returns the uint64 value for a given key.
+func (conf *ConfigEngine) Uint64(key string) (uint64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseUint64(v)
+}
+
+// Duration returns the time.Duration value, given as "1m30s", see config.ParseDuration for a given key.
+func (conf *ConfigEngine) Duration(key string) (time.Duration, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseDuration(v)
+}
+
+// Bytes returns the size in bytes, given as "16MB", "512KiB" or in bytes for a given key.
+func (conf *ConfigEngine) Bytes(key string) (int64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return 0, err
+	}
+	return config.ParseBytes(v)
+}
+
+// Time returns the time.Time value, see config.ParseTime for a given key.
+func (conf *ConfigEngine) Time(key string) (time.Time, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.ParseTime(v)
+}
+
+// StringMap returns the section at key with its values as strings.
+func (conf *ConfigEngine) StringMap(key string) (map[string]string, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseStringMap(v)
+}
+
+// IntSlice returns the []int value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) IntSlice(key string) ([]int, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseIntSlice(v)
+}
+
+// Float64Slice returns the []float64 value for a given key, a list or a ";" separated string.
+func (conf *ConfigEngine) Float64Slice(key string) ([]float64, error) {
+	v, err := conf.getData(key)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseFloat64Slice(v)
+}
+
+// Sub returns the section at key as a Configer sharing its data, so Set on
+// it changes this config too. It returns nil if key is not a section.
+func (conf *ConfigEngine) Sub(key string) config.Configer {
+	v, err := config.Lookup(conf.Data, key)
+	if m, ok := v.(map[string]interface{}); ok && err == nil {
+		return &ConfigEngine{Data: m}
+	}
+	return nil
+}
+
+// Unmarshal decodes the section at key, or the whole config if key is empty,
+// into the struct pointed to by out.
+func (conf *ConfigEngine) Unmarshal(key string, out interface{}) error {
+	if key == "" {
+		data, err := config.ResolveValue(conf.Data)
+		if err != nil {
+			return err
+		}
+		return config.Decode(data, out)
+	}
+	v, err := conf.getData(key)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	return config.Decode(v, out)
+}
 
-	r, err := yaml.Marshal(conf.Data)
+// RawData returns the parsed config map.
+func (conf *ConfigEngine) RawData() map[string]interface{} {
+	return conf.Data
+}
+
+// Locate returns the file and line where key is defined.
+func (conf *ConfigEngine) Locate(key string) (string, int, bool) {
+	return conf.positions.Locate(key)
+}
+
+// Set sets the value of key, creating missing sections. The value is stored
+// the way the yaml decoder returns it, so the getters treat it like parsed data.
+func (conf *ConfigEngine) Set(key string, value interface{}) error {
+	b, err := yaml.Marshal(value)
 	if err != nil {
 		return err
 	}
-	_, err = f.Write(r)
-	return err
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if conf.Data == nil {
+		conf.Data = make(map[string]interface{})
+	}
+	return config.SetPath(conf.Data, key, v)
 }
 
-func (conf *ConfigEngine) getData(key string) (interface{}, error) {
-	if len(key) == 0 {
-		return nil, fmt.Errorf("key is empty")
-	}
-
-	keys := strings.Split(key, ".")
-	tmpData := conf.Data
-
-	for idx, k := range keys {
-		if v, ok := tmpData[k]; ok {
-			switch v.(type) {
-			case map[string]interface{}:
-				{
-					tmpData = v.(map[string]interface{})
-					if idx == len(keys)-1 {
-						return tmpData, nil
-					}
-				}
-			case map[interface{}]interface{}:
-				{
-					tmpData = make(map[string]interface{})
-					for k, value := range v.(map[interface{}]interface{}) {
-						if keyTemp, ok := k.(string); ok {
-							tmpData[keyTemp] = value
-						}
-					}
-				}
-			default:
-				{
-					return v, nil
-				}
+// Delete removes key from the config.
+func (conf *ConfigEngine) Delete(key string) error {
+	return config.DeletePath(conf.Data, key)
+}
+
+// SetIncludes records the files composed into the config, see config.Includer.
+func (conf *ConfigEngine) SetIncludes(files []string) {
+	conf.includes = files
+}
+
+// Includes returns the files composed into the config.
+func (conf *ConfigEngine) Includes() []string {
+	return conf.includes
+}
+
+// SetOverlay records the environment variables overlaid on the config, see
+// config.Overlayer.
+func (conf *ConfigEngine) SetOverlay(vars []string) {
+	conf.overlay = vars
+}
+
+// Marshal returns the config in yaml. Comments and the order of the keys of
+// the loaded file are kept, new keys are appended to their section.
+func (conf *ConfigEngine) Marshal() ([]byte, error) {
+	if conf.node == nil || len(conf.node.Content) == 0 {
+		conf.node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{}}}
+	}
+	if err := syncNode(conf.node.Content[0], conf.Data); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(conf.node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveConfigFile save the config into file, see Marshal.
+// A config composed from other files or overlaid with environment
+// variables cannot be saved, see config.Includer and config.Overlayer.
+func (conf *ConfigEngine) SaveConfigFile(filename string) (err error) {
+	if len(conf.overlay) > 0 {
+		return &config.OverlayError{Vars: conf.overlay}
+	}
+	if len(conf.includes) > 0 {
+		return &config.ComposedError{Files: conf.includes}
+	}
+	b, err := conf.Marshal()
+	if err != nil {
+		return err
+	}
+	return config.WriteFile(filename, b)
+}
+
+// syncNode updates node in place to represent value, reusing the existing
+// nodes where possible so that their comments and order survive.
+func syncNode(node *yaml.Node, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if node.Kind == yaml.MappingNode {
+			return syncMapping(node, v)
+		}
+	case []interface{}:
+		if node.Kind == yaml.SequenceNode {
+			return syncSequence(node, v)
+		}
+	default:
+		var old interface{}
+		if node.Kind != 0 && node.Decode(&old) == nil && reflect.DeepEqual(old, value) {
+			return nil
+		}
+	}
+
+	var n yaml.Node
+	if err := n.Encode(value); err != nil {
+		return err
+	}
+	n.HeadComment, n.LineComment, n.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	*node = n
+	return nil
+}
+
+func syncMapping(node *yaml.Node, m map[string]interface{}) error {
+	seen := make(map[string]bool, len(m))
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		val, ok := m[k.Value]
+		if !ok {
+			continue
+		}
+		if err := syncNode(v, val); err != nil {
+			return err
+		}
+		seen[k.Value] = true
+		content = append(content, k, v)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := &yaml.Node{}
+		if err := v.Encode(m[k]); err != nil {
+			return err
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, v)
+	}
+	node.Content = content
+	return nil
+}
 
+func syncSequence(node *yaml.Node, list []interface{}) error {
+	if len(node.Content) > len(list) {
+		node.Content = node.Content[:len(list)]
+	}
+	for i, val := range list {
+		if i < len(node.Content) {
+			if err := syncNode(node.Content[i], val); err != nil {
+				return err
 			}
+			continue
+		}
+		v := &yaml.Node{}
+		if err := v.Encode(val); err != nil {
+			return err
 		}
+		node.Content = append(node.Content, v)
+	}
+	return nil
+}
+
+// getData resolves key with config.Lookup and the secrets in its value with
+// config.ResolveValue.
+func (conf *ConfigEngine) getData(key string) (interface{}, error) {
+	v, err := config.Lookup(conf.Data, key)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("not exist key %q", key)
+	return config.ResolveValue(v)
 }
@@ -1,8 +1,15 @@
 package yaml_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/fengfenghuo/go-common-lib/config"
 )
@@ -16,3 +23,314 @@ func TestYamlConfig(t *testing.T) {
 
 	fmt.Println(conf.String("db.module"))
 }
+
+func TestYamlUnmarshal(t *testing.T) {
+	conf, err := config.NewConfig("yaml", "conf.yaml")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	var db struct {
+		Module  string        `yaml:"module"`
+		MaxIdle int           `yaml:"maxIdle"`
+		MaxConn int           `yaml:"maxConn"`
+		Timeout time.Duration `yaml:"timeout" default:"3s"`
+	}
+	if err := conf.Unmarshal("db", &db); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if db.Module != "bee" || db.MaxIdle != 50 || db.MaxConn != 300 || db.Timeout != 3*time.Second {
+		t.Errorf("unexpected db config: %+v", db)
+	}
+}
+
+func TestYamlEnvOverlay(t *testing.T) {
+	t.Setenv("APP_DB_MODULE", "orm")
+	t.Setenv("APP_DB_MAXCONN", "10")
+
+	conf, err := config.NewConfig("yaml", "conf.yaml", config.WithEnv("APP"))
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db.module"); v != "orm" {
+		t.Errorf("db.module = %q, want orm", v)
+	}
+	if v, err := conf.Int("db.maxConn"); err != nil || v != 10 {
+		t.Errorf("db.maxConn = %d, %v, want 10", v, err)
+	}
+	if v := conf.String("runmode"); v != "dev" {
+		t.Errorf("runmode = %q, want dev", v)
+	}
+}
+
+func TestYamlEnvOverlaySave(t *testing.T) {
+	t.Setenv("TEST_YAML_DBPW", "s3cret")
+	file := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(file, []byte("db:\n  password: ${env:TEST_YAML_DBPW}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// without overriding variables the config saves its placeholders
+	conf, err := config.NewConfig("yaml", file, config.WithEnv("APP"))
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	conf.Set("db.module", "bee")
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+
+	t.Setenv("APP_DB_PASSWORD", "hunter2")
+	conf, err = config.NewConfig("yaml", file, config.WithEnv("APP"))
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db.password"); v != "hunter2" {
+		t.Errorf("db.password = %q, want hunter2", v)
+	}
+	var overlaid *config.OverlayError
+	if err := conf.SaveConfigFile(file); !errors.As(err, &overlaid) || overlaid.Vars[0] != "APP_DB_PASSWORD" {
+		t.Errorf("SaveConfigFile error = %v, want OverlayError for APP_DB_PASSWORD", err)
+	}
+	if b, _ := os.ReadFile(file); strings.Contains(string(b), "hunter2") || !strings.Contains(string(b), "${env:TEST_YAML_DBPW}") {
+		t.Errorf("saved file:\n%s", b)
+	}
+}
+
+func TestYamlKeyPath(t *testing.T) {
+	conf, err := config.NewConfig("yaml", "conf.yaml")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db::module"); v != "bee" {
+		t.Errorf("db::module = %q, want bee", v)
+	}
+	if v := conf.String("servers[1].host"); v != "10.0.0.2" {
+		t.Errorf("servers[1].host = %q, want 10.0.0.2", v)
+	}
+	if v, err := conf.Int("servers[0].port"); err != nil || v != 8001 {
+		t.Errorf("servers[0].port = %d, %v, want 8001", v, err)
+	}
+	var ports []int
+	if err := conf.Unmarshal("servers[*].port", &ports); err != nil || len(ports) != 2 || ports[1] != 8002 {
+		t.Errorf("servers[*].port = %v, %v", ports, err)
+	}
+}
+
+func TestYamlSchema(t *testing.T) {
+	schema := &config.Schema{
+		Fields: []config.Field{
+			{Key: "httpport", Type: "bool"},
+			{Key: "servers[*].host", Type: "list"},
+			{Key: "servers[1].port", Type: "string"},
+		},
+	}
+
+	_, err := config.NewConfig("yaml", "conf.yaml", config.WithSchema(schema))
+	verr, ok := err.(*config.ValidationError)
+	if !ok || len(verr.Violations) != 2 {
+		t.Fatalf("expected 2 violations, got %v", err)
+	}
+	if v := verr.Violations[0]; v.Key != "httpport" || v.Line != 3 {
+		t.Errorf("unexpected violation %s", v)
+	}
+	if v := verr.Violations[1]; v.Key != "servers[1].port" || v.Line != 14 {
+		t.Errorf("unexpected violation %s", v)
+	}
+}
+
+func TestYamlSetSave(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "conf.yaml")
+	src := `# service config
+runmode: dev # dev or prod
+db:
+  # connection
+  module: bee
+  maxIdle: 50
+httpport: 38080
+`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, file)
+
+	conf, err := config.NewConfig("yaml", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if err := conf.Set("runmode", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Set("db.maxConn", 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Delete("db.maxIdle"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := conf.Int("db.maxConn"); err != nil || v != 300 {
+		t.Errorf("db.maxConn = %d, %v", v, err)
+	}
+	if err := conf.SaveConfigFile(file); err != nil {
+		t.Fatalf("SaveConfigFile error: %v", err)
+	}
+
+	got, _ := os.ReadFile(file)
+	want := `# service config
+runmode: prod # dev or prod
+db:
+  # connection
+  module: bee
+  maxConn: 300
+httpport: 38080
+`
+	if string(got) != want {
+		t.Errorf("saved file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestYamlSecrets(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "s3cret")
+
+	file := filepath.Join(t.TempDir(), "conf.yaml")
+	if err := os.WriteFile(file, []byte("db:\n  password: ${env:TEST_DB_PASSWORD}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, file)
+
+	conf, err := config.NewConfig("yaml", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+	if v := conf.String("db.password"); v != "s3cret" {
+		t.Errorf("db.password = %q", v)
+	}
+}
+
+func TestYamlTypedGetters(t *testing.T) {
+	conf, err := config.NewConfig("yaml", "conf.yaml")
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v, err := conf.Duration("timeout"); err != nil || v != 90*time.Second {
+		t.Errorf("Duration(timeout) = %v, %v", v, err)
+	}
+	if v, err := conf.Bytes("maxsize"); err != nil || v != 16<<20 {
+		t.Errorf("Bytes(maxsize) = %d, %v", v, err)
+	}
+	if v, err := conf.Time("released"); err != nil || !v.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("Time(released) = %v, %v", v, err)
+	}
+	if v, err := conf.Uint64("httpport"); err != nil || v != 38080 {
+		t.Errorf("Uint64(httpport) = %d, %v", v, err)
+	}
+	if v := conf.Strings("tags"); !reflect.DeepEqual(v, []string{"api", "billing"}) {
+		t.Errorf("Strings(tags) = %v", v)
+	}
+	if v, err := conf.IntSlice("servers[*].port"); err != nil || !reflect.DeepEqual(v, []int{8001, 8002}) {
+		t.Errorf("IntSlice(servers[*].port) = %v, %v", v, err)
+	}
+	if v, err := conf.Float64Slice("weights"); err != nil || !reflect.DeepEqual(v, []float64{0.5, 1.5}) {
+		t.Errorf("Float64Slice(weights) = %v, %v", v, err)
+	}
+	if v, err := conf.StringMap("db"); err != nil || v["module"] != "bee" || v["maxConn"] != "300" {
+		t.Errorf("StringMap(db) = %v, %v", v, err)
+	}
+
+	db := conf.Sub("db")
+	if db == nil {
+		t.Fatal("Sub(db) = nil")
+	}
+	if v, err := db.Int("maxIdle"); err != nil || v != 50 {
+		t.Errorf("Sub(db).Int(maxIdle) = %d, %v", v, err)
+	}
+	if conf.Sub("appname") != nil {
+		t.Error("Sub(appname) is not nil")
+	}
+}
+
+func TestYamlInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("conf.yaml", `include:
+  - base.yaml
+  - conf.d/*.yaml
+runmode: prod
+db:
+  maxConn: 500
+`)
+	write("base.yaml", `runmode: dev
+db:
+  module: bee
+  maxConn: 300
+tags: [a, b]
+`)
+	write("conf.d/10-tags.yaml", "tags: [c]\n")
+	write("conf.d/20-cache.yaml", "cache:\n  $ref: ../cache.yaml#redis\n  db: 2\n")
+	write("cache.yaml", "redis:\n  addr: localhost:6379\n  db: 0\n")
+
+	wd, _ := os.Getwd()
+	rel, _ := filepath.Rel(wd, filepath.Join(dir, "conf.yaml"))
+	conf, err := config.NewConfig("yaml", rel)
+	if err != nil {
+		t.Fatalf("NewConfig error: %v", err)
+	}
+
+	if v := conf.String("runmode"); v != "prod" {
+		t.Errorf("runmode = %q, want prod", v)
+	}
+	if v := conf.String("db.module"); v != "bee" {
+		t.Errorf("db.module = %q, want bee", v)
+	}
+	if v, _ := conf.Int("db.maxConn"); v != 500 {
+		t.Errorf("db.maxConn = %d, want 500", v)
+	}
+	if v := conf.Strings("tags"); !reflect.DeepEqual(v, []string{"c"}) {
+		t.Errorf("tags = %v, want [c]", v)
+	}
+	if v := conf.String("cache.addr"); v != "localhost:6379" {
+		t.Errorf("cache.addr = %q", v)
+	}
+	if v, _ := conf.Int("cache.db"); v != 2 {
+		t.Errorf("cache.db = %d, want 2", v)
+	}
+	if conf.String("include") != "" || conf.Strings("include") != nil {
+		t.Error("include directive is visible in the config")
+	}
+
+	write("base.yaml", "include: conf.yaml\n")
+	if _, err := config.NewConfig("yaml", rel); err == nil {
+		t.Error("NewConfig with an include cycle succeeded")
+	}
+}
+
+func TestYamlFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf.yaml":          {Data: []byte("include: conf.d/*.yaml\nrunmode: prod\n")},
+		"conf.d/db.yaml":     {Data: []byte("db:\n  module: bee\n")},
+		"conf.d/server.yaml": {Data: []byte("runmode: dev\nhttpport: 38080\n")},
+	}
+	conf, err := config.NewConfigFromFS("yaml", fsys, "conf.yaml")
+	if err != nil {
+		t.Fatalf("NewConfigFromFS error: %v", err)
+	}
+	if v := conf.String("runmode"); v != "prod" {
+		t.Errorf("runmode = %q, want prod", v)
+	}
+	if v := conf.String("db.module"); v != "bee" {
+		t.Errorf("db.module = %q, want bee", v)
+	}
+	if v, _ := conf.Int("httpport"); v != 38080 {
+		t.Errorf("httpport = %d, want 38080", v)
+	}
+}
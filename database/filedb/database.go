@@ -33,9 +33,15 @@ type LDBDatabase struct {
 	log *logger.Logger // Contextual logger tracking the database path
 }
 
-// NewLDBDatabase returns a LevelDB wrapped object.
+// NewLDBDatabase returns a LevelDB wrapped object, logging to the filedb
+// module logger.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
-	logger := logger.NewLogInstance("filedb", "debug", "", "")
+	return NewLDBDatabaseWithLogger(file, cache, handles, logger.NewLogInstance("filedb", "debug", "", ""))
+}
+
+// NewLDBDatabaseWithLogger returns a LevelDB wrapped object logging to log,
+// e.g. a logger of package logtest in tests.
+func NewLDBDatabaseWithLogger(file string, cache int, handles int, log *logger.Logger) (*LDBDatabase, error) {
 
 	// Ensure we have some minimal caching and file guarantees
 	if cache < 16 {
@@ -44,7 +50,7 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	if handles < 16 {
 		handles = 16
 	}
-	logger.Info("Allocated cache and file handles", logger.Int("cache", cache), logger.Int("handles", handles))
+	log.Info("Allocated cache and file handles", log.Int("cache", cache), log.Int("handles", handles))
 
 	// Open the db and recover any potential corruptions
 	db, err := leveldb.OpenFile(file, &opt.Options{
@@ -63,7 +69,7 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	return &LDBDatabase{
 		fn:  file,
 		db:  db,
-		log: logger,
+		log: log,
 	}, nil
 }
 
@@ -9,15 +9,17 @@ import (
 	"sync"
 	"testing"
 
-	"github.com/fengfenghuo/go-common-lib/filedb"
+	"github.com/fengfenghuo/go-common-lib/database/filedb"
+	"github.com/fengfenghuo/go-common-lib/log/logtest"
 )
 
-func newTestLDB() (*filedb.LDBDatabase, func()) {
+func newTestLDB(t *testing.T) (*filedb.LDBDatabase, func()) {
 	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
 	if err != nil {
 		panic("failed to create test file: " + err.Error())
 	}
-	db, err := filedb.NewLDBDatabase(dirname, 0, 0)
+	log, _ := logtest.New(t)
+	db, err := filedb.NewLDBDatabaseWithLogger(dirname, 0, 0, log)
 	if err != nil {
 		panic("failed to create test database: " + err.Error())
 	}
@@ -31,7 +33,7 @@ func newTestLDB() (*filedb.LDBDatabase, func()) {
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
-	db, remove := newTestLDB()
+	db, remove := newTestLDB(t)
 	defer remove()
 	testPutGet(db, t)
 }
@@ -109,7 +111,7 @@ func testPutGet(db filedb.Database, t *testing.T) {
 }
 
 func TestLDB_ParallelPutGet(t *testing.T) {
-	db, remove := newTestLDB()
+	db, remove := newTestLDB(t)
 	defer remove()
 	testParallelPutGet(db, t)
 }
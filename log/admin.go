@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultAdminAddr = "127.0.0.1:9090"
+
+type levelRequest struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+}
+
+type levelResponse struct {
+	Logger  string            `json:"logger"`
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// LevelHandler returns the handler of the log level endpoint, to be mounted
+// on any mux:
+//
+//	GET  /log/level                  the level of logger and of all loggers
+//	GET  /log/level?logger=db.conn   the level of the logger db.conn
+//	PUT  /log/level {"level":"info"} set the level of logger
+//	PUT  /log/level {"logger":"db.conn","level":"debug"}
+//
+// Loggers are module loggers, named by their ModuleName, and their children
+// made by Named. The listing holds the module loggers and the children given
+// a level of their own. An empty level makes a child follow its parent again.
+// If token is not empty, requests must send "Authorization: Bearer <token>".
+func (logger *Logger) LevelHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && !validToken(r, token) {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid token"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			name := r.URL.Query().Get("logger")
+			if name == "" {
+				res := levelResponse{Loggers: allLevels()}
+				if logger.node != nil {
+					res.Logger, res.Level = logger.node.name, logger.node.Level().String()
+				}
+				writeJSON(w, http.StatusOK, res)
+				return
+			}
+			n, ok := findLevel(name)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown logger %q", name)})
+				return
+			}
+			writeJSON(w, http.StatusOK, levelResponse{Logger: n.name, Level: n.Level().String()})
+		case http.MethodPut, http.MethodPost:
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body: " + err.Error()})
+				return
+			}
+			if req.Logger == "" {
+				req.Logger = r.URL.Query().Get("logger")
+			}
+			n, status, err := logger.setLevel(req)
+			if err != nil {
+				writeJSON(w, status, errorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, levelResponse{Logger: n.name, Level: n.Level().String()})
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only GET, PUT and POST are supported"})
+		}
+	})
+}
+
+func (logger *Logger) setLevel(req levelRequest) (*levelNode, int, error) {
+	n := logger.node
+	if req.Logger != "" {
+		var ok bool
+		if n, ok = findLevel(req.Logger); !ok {
+			return nil, http.StatusNotFound, fmt.Errorf("unknown logger %q", req.Logger)
+		}
+	}
+	if n == nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("logger is required")
+	}
+
+	if req.Level == "" {
+		if !n.Reset() {
+			return nil, http.StatusBadRequest, fmt.Errorf("level is required for module logger %q", n.name)
+		}
+		return n, http.StatusOK, nil
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(req.Level)); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	n.SetLevel(l)
+	return n, http.StatusOK, nil
+}
+
+func validToken(r *http.Request, token string) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// serveLevel mounts the log level endpoint at /log/level, on config.AdminMux
+// if set and otherwise on a server listening on config.AdminAddr.
+func serveLevel(logger *Logger, config LoggerConfig) {
+	handler := logger.LevelHandler(config.AdminToken)
+	if config.AdminMux != nil {
+		config.AdminMux.Handle("/log/level", handler)
+		return
+	}
+
+	addr := config.AdminAddr
+	if addr == "" {
+		addr = defaultAdminAddr
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/log/level", handler)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logger.Error("log level endpoint stopped", zap.String("addr", addr), zap.Error(err))
+		}
+	}()
+}
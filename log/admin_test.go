@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLevelHandler(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	config := NewLoggerConfig(LoggerConfig{
+		ModuleName:    "admintest",
+		Level:         "info",
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		DisableStdout: true,
+		Sinks:         []Sink{{Type: "writer", Writer: &out}},
+	})
+	logger := initLogger(config, zap.NewAtomicLevelAt(getLoggerLevel(config.Level)))
+	conn := logger.Named("conn")
+
+	srv := httptest.NewServer(logger.LevelHandler("s3cret"))
+	defer srv.Close()
+	do := func(method, body, token string) (int, levelResponse) {
+		req, _ := http.NewRequest(method, srv.URL+"/log/level", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res levelResponse
+		json.NewDecoder(resp.Body).Decode(&res)
+		return resp.StatusCode, res
+	}
+
+	if code, _ := do(http.MethodGet, "", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("GET with wrong token = %d, want 401", code)
+	}
+	code, res := do(http.MethodGet, "", "s3cret")
+	if code != http.StatusOK || res.Level != "info" || res.Loggers["admintest"] != "info" {
+		t.Errorf("GET = %d %+v", code, res)
+	}
+	if code, res = do(http.MethodGet, "", "s3cret"); res.Loggers["admintest.conn"] != "" {
+		t.Errorf("GET lists child without a level: %+v", res)
+	}
+
+	if code, res = do(http.MethodPut, `{"logger":"admintest.conn","level":"debug"}`, "s3cret"); code != http.StatusOK || res.Level != "debug" {
+		t.Errorf("PUT child = %d %+v", code, res)
+	}
+	conn.Debug("child debug")
+	logger.Debug("module debug")
+	if !strings.Contains(out.String(), "child debug") || strings.Contains(out.String(), "module debug") {
+		t.Errorf("output after changing the child level:\n%s", out.String())
+	}
+
+	if _, res = do(http.MethodGet, "", "s3cret"); res.Loggers["admintest.conn"] != "debug" {
+		t.Errorf("GET after PUT child = %+v", res)
+	}
+
+	// the module level applies to children following it
+	do(http.MethodPut, `{"logger":"admintest.conn","level":""}`, "s3cret")
+	if _, res = do(http.MethodGet, "", "s3cret"); res.Loggers["admintest.conn"] != "" {
+		t.Errorf("GET lists reset child: %+v", res)
+	}
+	do(http.MethodPut, `{"level":"error"}`, "s3cret")
+	conn.Warn("child warn")
+	if strings.Contains(out.String(), "child warn") {
+		t.Errorf("child ignores the module level:\n%s", out.String())
+	}
+
+	if code, _ = do(http.MethodPut, `{"level":"loud"}`, "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("PUT invalid level = %d, want 400", code)
+	}
+	if code, _ = do(http.MethodPut, `{"logger":"nothing","level":"info"}`, "s3cret"); code != http.StatusNotFound {
+		t.Errorf("PUT unknown logger = %d, want 404", code)
+	}
+}
+
+func TestNamedNotRegistered(t *testing.T) {
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ModuleName:    "namedtest",
+		Level:         "info",
+		ErrorPath:     filepath.Join(t.TempDir(), "error.log"),
+		LogPath:       filepath.Join(t.TempDir(), "log.log"),
+		DisableStdout: true,
+	}), zap.NewAtomicLevel())
+
+	before := len(allLevels())
+	for i := 0; i < 100; i++ {
+		logger.Named(fmt.Sprintf("req%d", i)).Debug("handled")
+	}
+	if n := len(allLevels()); n != before {
+		t.Errorf("Named registered children: %d loggers, want %d", n, before)
+	}
+
+	n, ok := findLevel("namedtest.req7.sql")
+	if !ok {
+		t.Fatal("child of a module logger not found")
+	}
+	n.SetLevel(zap.DebugLevel)
+	sql := logger.Named("req7").Named("sql")
+	if !sql.node.Enabled(zap.DebugLevel) || logger.Named("req8").node.Enabled(zap.DebugLevel) {
+		t.Error("level of namedtest.req7.sql not applied to it only")
+	}
+	n.Reset()
+	if sql.node.Enabled(zap.DebugLevel) || len(allLevels()) != before {
+		t.Error("reset child keeps its level")
+	}
+}
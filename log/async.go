@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultBufferSize    = 4096
+	defaultFlushInterval = time.Second
+)
+
+// asyncWriter buffers the entries written to out in a ring and writes them
+// from a background goroutine, every interval or as soon as the ring is half
+// full.
+type asyncWriter struct {
+	out      zapcore.WriteSyncer
+	interval time.Duration
+	block    bool
+	drops    *dropCounters
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    [][]byte
+	head    int // index of the oldest entry
+	n       int // number of entries in ring
+	closed  bool
+
+	flushMu sync.Mutex // keeps the batches in order on out
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newAsyncWriter(out zapcore.WriteSyncer, config LoggerConfig, drops *dropCounters) *asyncWriter {
+	size := config.BufferSize
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	interval := config.FlushInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	w := &asyncWriter{
+		out:      out,
+		interval: interval,
+		block:    config.BlockWhenFull,
+		drops:    drops,
+		ring:     make([][]byte, size),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-w.wake:
+			w.flush()
+		case <-w.stop:
+			w.flush()
+			return
+		}
+	}
+}
+
+// Write buffers a copy of p, zap reuses its buffers. When the ring is full p
+// is dropped, or Write waits for the next flush if the writer blocks.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	for w.n == len(w.ring) && w.block && !w.closed {
+		w.notFull.Wait()
+	}
+	if w.closed {
+		// after Close entries go straight to out
+		w.mu.Unlock()
+		w.flushMu.Lock()
+		defer w.flushMu.Unlock()
+		return w.out.Write(p)
+	}
+	if w.n == len(w.ring) {
+		w.mu.Unlock()
+		w.drops.buffer.Add(1)
+		return len(p), nil
+	}
+	w.ring[(w.head+w.n)%len(w.ring)] = append([]byte(nil), p...)
+	w.n++
+	half := w.n >= len(w.ring)/2
+	w.mu.Unlock()
+
+	if half {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// flush writes the buffered entries to out.
+func (w *asyncWriter) flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	batch := make([][]byte, 0, w.n)
+	for ; w.n > 0; w.n-- {
+		batch = append(batch, w.ring[w.head])
+		w.ring[w.head] = nil
+		w.head = (w.head + 1) % len(w.ring)
+	}
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	var err error
+	for _, p := range batch {
+		if _, werr := w.out.Write(p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// Sync writes the buffered entries, zap calls it before a panic or fatal
+// exit.
+func (w *asyncWriter) Sync() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.out.Sync()
+}
+
+// Close stops the background goroutine once the buffer is written, or
+// returns ctx.Err() if ctx is done first.
+func (w *asyncWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+		w.notFull.Broadcast()
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return w.out.Sync()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the buffered entries and closes the log and error files, or
+// returns ctx.Err() if ctx is done first. It closes the files of the children
+// of logger too. Entries logged after Close are written straight to the
+// files, which are opened again.
+func (logger *Logger) Close(ctx context.Context) error {
+	var errs []string
+	for _, w := range logger.async {
+		if err := w.Close(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	for _, f := range logger.files {
+		if err := f.Reopen(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("logger: close: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type syncBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *syncBuffer) Sync() error { return nil }
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.String()
+}
+
+// newStoppedWriter returns an asyncWriter without its goroutine, flushed by
+// hand.
+func newStoppedWriter(size int, block bool) (*asyncWriter, *syncBuffer) {
+	out := &syncBuffer{}
+	w := &asyncWriter{out: out, block: block, drops: &dropCounters{},
+		ring: make([][]byte, size), wake: make(chan struct{}, 1)}
+	w.notFull = sync.NewCond(&w.mu)
+	return w, out
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	w, out := newStoppedWriter(2, false)
+	for _, s := range []string{"a", "b", "c"} {
+		w.Write([]byte(s))
+	}
+	if out.String() != "" {
+		t.Errorf("written before flush: %q", out.String())
+	}
+	if err := w.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "ab" || w.drops.buffer.Load() != 1 {
+		t.Errorf("out = %q, dropped = %d", out.String(), w.drops.buffer.Load())
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	w, out := newStoppedWriter(1, true)
+	w.Write([]byte("a"))
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("b"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Write did not wait for a full buffer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	w.flush()
+	<-done
+	w.flush()
+	if out.String() != "ab" {
+		t.Errorf("out = %q", out.String())
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	dir := t.TempDir()
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		DisableStdout: true,
+		Async:         true,
+		FlushInterval: time.Hour,
+	}), zap.NewAtomicLevelAt(zap.DebugLevel))
+	for i := 0; i < 10; i++ {
+		logger.Info("buffered")
+	}
+	logger.Error("failed")
+
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "log.log"))
+	if n := strings.Count(string(b), "buffered"); n != 10 {
+		t.Errorf("got %d entries in log.log, want 10", n)
+	}
+	b, _ = os.ReadFile(filepath.Join(dir, "error.log"))
+	if !strings.Contains(string(b), "failed") {
+		t.Errorf("error.log = %q", b)
+	}
+
+	logger.Info("after close")
+	b, _ = os.ReadFile(filepath.Join(dir, "log.log"))
+	if !strings.Contains(string(b), "after close") {
+		t.Error("entry after Close not written")
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+var nopLogger = newLogger(zap.NewNop(), LoggerConfig{})
+
+// IntoContext returns a copy of ctx carrying logger, typically a child logger
+// made by With that holds request-scoped fields such as a trace ID.
+func IntoContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by IntoContext. Without one it
+// returns the first logger created by FindOrCreateLoggerInstance, and a
+// logger discarding everything otherwise, so the result is never nil.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	if logger := defaultLogger(); logger != nil {
+		return logger
+	}
+	return nopLogger
+}
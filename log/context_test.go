@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newObservedLogger returns the logger of config, built by NewWithCore like
+// the module loggers, keeping its entries in memory.
+func newObservedLogger(config LoggerConfig) (*Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return NewWithCore(core, config), logs
+}
+
+func TestWithAndNamed(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{})
+	child := logger.Named("db").With(zap.String("trace_id", "abc"))
+	child.Named("conn").Info("connected", zap.Int("port", 3306))
+	logger.Info("plain")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if e.LoggerName != "db.conn" {
+		t.Errorf("logger name = %q, want db.conn", e.LoggerName)
+	}
+	fields := e.ContextMap()
+	if fields["trace_id"] != "abc" || fields["port"] != int64(3306) {
+		t.Errorf("fields = %v", fields)
+	}
+	if len(entries[1].Context) != 0 || entries[1].LoggerName != "" {
+		t.Errorf("parent logger changed: %+v", entries[1])
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) == nil {
+		t.Fatal("FromContext without logger returned nil")
+	}
+
+	logger, logs := newObservedLogger(LoggerConfig{})
+	ctx := IntoContext(context.Background(), logger.With(zap.String("user_id", "42")))
+	FromContext(ctx).Info("request")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].ContextMap()["user_id"] != "42" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is a log entry as passed to hooks. Caller is defined if the logger
+// is configured with Caller, Stack if the level is at StacktraceLevel.
+type Entry struct {
+	zapcore.Entry
+	// Fields are the fields of the entry and of the logger, redacted if
+	// the logger is.
+	Fields map[string]interface{}
+}
+
+type hook struct {
+	level zapcore.Level
+	fn    func(Entry)
+}
+
+// hookSet is the hooks of a module logger, shared by its children.
+type hookSet struct {
+	mu    sync.RWMutex
+	hooks []*hook
+}
+
+// AddHook calls fn for every entry at level and above written by logger and
+// its children, after the level, sampling and rate limiting checks. fn runs
+// in the goroutine that logs, it must be fast and must not log to the same
+// logger. The returned func removes the hook.
+//
+//	remove := logger.AddHook(zap.ErrorLevel, func(e logger.Entry) {
+//		errorsTotal.WithLabelValues(e.LoggerName).Inc()
+//	})
+func (logger *Logger) AddHook(level zapcore.Level, fn func(Entry)) (remove func()) {
+	hs := logger.hooks
+	if hs == nil {
+		return func() {}
+	}
+	h := &hook{level: level, fn: fn}
+	hs.mu.Lock()
+	hs.hooks = append(hs.hooks, h)
+	hs.mu.Unlock()
+
+	return func() {
+		hs.mu.Lock()
+		defer hs.mu.Unlock()
+		for i, o := range hs.hooks {
+			if o == h {
+				hs.hooks = append(hs.hooks[:i:i], hs.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (hs *hookSet) enabled(l zapcore.Level) bool {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	for _, h := range hs.hooks {
+		if l >= h.level {
+			return true
+		}
+	}
+	return false
+}
+
+// hookCore is the output of a module logger that calls its hooks.
+type hookCore struct {
+	hooks   *hookSet
+	context []zapcore.Field
+}
+
+func (c *hookCore) Enabled(l zapcore.Level) bool {
+	return c.hooks.enabled(l)
+}
+
+func (c *hookCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+	return &hookCore{hooks: c.hooks, context: context}
+}
+
+func (c *hookCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *hookCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.hooks.mu.RLock()
+	var fns []func(Entry)
+	for _, h := range c.hooks.hooks {
+		if ent.Level >= h.level {
+			fns = append(fns, h.fn)
+		}
+	}
+	c.hooks.mu.RUnlock()
+	if len(fns) == 0 {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.context {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	for _, fn := range fns {
+		fn(Entry{Entry: ent, Fields: enc.Fields})
+	}
+	return nil
+}
+
+func (c *hookCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAddHook(t *testing.T) {
+	dir := t.TempDir()
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ModuleName:    "hooked",
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		DisableStdout: true,
+		Caller:        true,
+		Redact:        true,
+	}), zap.NewAtomicLevelAt(zap.DebugLevel))
+
+	var mu sync.Mutex
+	var got []Entry
+	remove := logger.AddHook(zapcore.WarnLevel, func(e Entry) {
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+	})
+
+	child := logger.Named("db").With(zap.String("dsn", "root:pw@tcp(db)/app"))
+	child.Info("ignored")
+	child.Warn("slow query", zap.Int("ms", 1200))
+	remove()
+	child.Error("after remove")
+
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	e := got[0]
+	if e.Level != zapcore.WarnLevel || e.Message != "hooked: slow query" || e.LoggerName != "db" {
+		t.Errorf("entry = %+v", e.Entry)
+	}
+	if !e.Caller.Defined || filepath.Base(e.Caller.File) != "hook_test.go" {
+		t.Errorf("caller = %v", e.Caller)
+	}
+	if e.Fields["ms"] != int64(1200) || e.Fields["dsn"] != redactMask {
+		t.Errorf("fields = %v", e.Fields)
+	}
+}
+
+func TestAddHookWithoutHooks(t *testing.T) {
+	nopLogger.AddHook(zapcore.DebugLevel, func(Entry) { t.Error("hook called") })()
+	nopLogger.Info("no hooks")
+}
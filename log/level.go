@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelNode is the level of a module logger or of one of its named children.
+// Children are not registered: a child has a level of its own only while one
+// is set through LevelHandler, and follows its parent otherwise.
+type levelNode struct {
+	name   string
+	parent *levelNode      // nil for a module logger
+	level  zap.AtomicLevel // level of a module logger
+}
+
+// Level returns the effective level of n.
+func (n *levelNode) Level() zapcore.Level {
+	own := *levels.children.Load()
+	for m := n; m != nil; m = m.parent {
+		if m.parent == nil {
+			return m.level.Level()
+		}
+		if l, ok := own[m.name]; ok {
+			return l
+		}
+	}
+	return zapcore.InfoLevel
+}
+
+func (n *levelNode) Enabled(l zapcore.Level) bool {
+	return l >= n.Level()
+}
+
+// SetLevel gives n a level of its own.
+func (n *levelNode) SetLevel(l zapcore.Level) {
+	if n.parent == nil {
+		n.level.SetLevel(l)
+		return
+	}
+	updateChildren(func(own map[string]zapcore.Level) {
+		own[n.name] = l
+	})
+}
+
+// Reset makes a child follow its parent again, forgetting its level. Module
+// loggers keep their level.
+func (n *levelNode) Reset() bool {
+	if n.parent == nil {
+		return false
+	}
+	updateChildren(func(own map[string]zapcore.Level) {
+		delete(own, n.name)
+	})
+	return true
+}
+
+var levels = struct {
+	sync.Mutex
+	nodes map[string]*levelNode // module loggers
+	// levels of the children set through LevelHandler, copied on write so
+	// that Level does not lock
+	children atomic.Pointer[map[string]zapcore.Level]
+}{nodes: make(map[string]*levelNode)}
+
+func init() {
+	levels.children.Store(&map[string]zapcore.Level{})
+}
+
+// updateChildren replaces the levels of the children by a copy changed by fn.
+func updateChildren(fn func(own map[string]zapcore.Level)) {
+	levels.Lock()
+	defer levels.Unlock()
+	old := *levels.children.Load()
+	own := make(map[string]zapcore.Level, len(old)+1)
+	for name, l := range old {
+		own[name] = l
+	}
+	fn(own)
+	levels.children.Store(&own)
+}
+
+// registerLevel registers the level of the module logger name, replacing a
+// previous logger of the same name.
+func registerLevel(name string, level zap.AtomicLevel) *levelNode {
+	if name == "" {
+		name = "default"
+	}
+	n := &levelNode{name: name, level: level}
+
+	levels.Lock()
+	defer levels.Unlock()
+	levels.nodes[name] = n
+	return n
+}
+
+// child returns the level of the child logger name of n.
+func (n *levelNode) child(name string) *levelNode {
+	return &levelNode{name: n.name + "." + name, parent: n}
+}
+
+// findLevel returns the module logger name, or the child name of a module
+// logger, e.g. db.conn of the module db.
+func findLevel(name string) (*levelNode, bool) {
+	levels.Lock()
+	defer levels.Unlock()
+	if n, ok := levels.nodes[name]; ok {
+		return n, true
+	}
+	for i := strings.LastIndex(name, "."); i > 0; i = strings.LastIndex(name[:i], ".") {
+		if m, ok := levels.nodes[name[:i]]; ok {
+			n := m
+			for _, part := range strings.Split(name[i+1:], ".") {
+				if part == "" {
+					return nil, false
+				}
+				n = n.child(part)
+			}
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// allLevels returns the effective level of every module logger and of the
+// children with a level of their own.
+func allLevels() map[string]string {
+	levels.Lock()
+	nodes := make([]*levelNode, 0, len(levels.nodes))
+	for _, n := range levels.nodes {
+		nodes = append(nodes, n)
+	}
+	levels.Unlock()
+
+	res := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		res[n.name] = n.Level().String()
+	}
+	for name, l := range *levels.children.Load() {
+		res[name] = l.String()
+	}
+	return res
+}
+
+// levelCore filters the entries of its core by the level of a levelNode, so
+// that the level of a logger can be changed at runtime independently of
+// the outputs.
+type levelCore struct {
+	zapcore.Core
+	node *levelNode
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.node.Enabled(l)
+}
+
+// Level lets zap report the level of the logger.
+func (c *levelCore) Level() zapcore.Level {
+	return c.node.Level()
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), node: c.node}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.node.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// withLevelNode returns log with the levelCore of its core switched to n.
+func withLevelNode(log *zap.Logger, n *levelNode) *zap.Logger {
+	return log.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if lc, ok := c.(*levelCore); ok {
+			return &levelCore{Core: lc.Core, node: n}
+		}
+		return c
+	}))
+}
@@ -1,11 +1,15 @@
 package logger
 
 import (
+	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type LoggerConfig struct {
@@ -14,16 +18,121 @@ type LoggerConfig struct {
 	ErrorPath  string
 	LogPath    string
 	HasHTTPNet bool
+
+	// Encoding is the encoding of every output, "console" (default) or
+	// "json". StdoutEncoding, LogEncoding and ErrorEncoding override it for
+	// the console, the log file and the error file.
+	Encoding       string
+	StdoutEncoding string
+	LogEncoding    string
+	ErrorEncoding  string
+	// DisableStdout turns the console output off.
+	DisableStdout bool
+	// Sinks are extra outputs besides the console and the log files.
+	Sinks []Sink
+	// TimeFormat is a time layout such as time.RFC3339, or one of iso8601
+	// (default), rfc3339, epoch, millis and nanos.
+	TimeFormat string
+	// Caller adds the file and line of the call site to every entry.
+	Caller bool
+	// StacktraceLevel adds a stack trace to the entries at this level and
+	// above, e.g. "error". Empty disables stack traces.
+	StacktraceLevel string
+
+	// MaxSize is the size in megabytes at which a file is rotated, 16 if
+	// zero. MaxBackups is the number of rotated files kept, all if zero.
+	// MaxAge is the number of days rotated files are kept, 30 if zero and
+	// forever if negative. Compress gzips rotated files.
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
+	// UTCTime names rotated files with UTC instead of local time.
+	UTCTime bool
+	// RotateEvery is "daily" or "hourly" to start a new file every day or
+	// hour. The date is inserted before the extension of LogPath and
+	// ErrorPath unless they contain %Y, %m, %d and %H placeholders, as in
+	// "./logs/app-%Y%m%d.log".
+	RotateEvery string
+	// ReopenOnSIGHUP reopens the files on SIGHUP, for logrotate.
+	ReopenOnSIGHUP bool
+
+	// SamplingFirst and SamplingThereafter sample the entries with the same
+	// level and message: every SamplingTick (1s if zero) the first
+	// SamplingFirst are written, then every SamplingThereafter-th, none if
+	// zero. Sampling is off if SamplingFirst is zero.
+	SamplingFirst      int
+	SamplingThereafter int
+	SamplingTick       time.Duration
+	// RateLimit writes at most RateLimit entries with the same level, logger
+	// name and message every RateLimitInterval (1 minute if zero), for the
+	// entries at RateLimitLevel ("error" if empty) and above. Off if zero.
+	// Dropped counts the entries left out.
+	RateLimit         int
+	RateLimitInterval time.Duration
+	RateLimitLevel    string
+
+	// Async writes the log files from a buffer of BufferSize entries (4096 if
+	// zero) by a background goroutine, every FlushInterval (1s if zero) or as
+	// soon as the buffer is half full, so that logging does not wait for the
+	// disk. When the buffer is full entries are dropped and counted in
+	// Dropped, or the caller waits if BlockWhenFull is set. Close writes the
+	// buffer before shutdown.
+	Async         bool
+	BufferSize    int
+	FlushInterval time.Duration
+	BlockWhenFull bool
+
+	// Redact masks secrets before entries are written: the values of the
+	// fields whose key contains one of RedactKeys, and in messages and string
+	// and error fields the key=value pairs of those keys, DSN passwords and
+	// the matches of RedactPatterns. Setting RedactKeys or RedactPatterns
+	// turns it on too; empty lists use DefaultRedactKeys and
+	// DefaultRedactPatterns.
+	Redact         bool
+	RedactKeys     []string
+	RedactPatterns []string
+
+	// AdminAddr is the address the log level endpoint of HasHTTPNet listens
+	// on, 127.0.0.1:9090 by default. If AdminMux is set, the endpoint is
+	// mounted on it instead. AdminToken protects the endpoint, see
+	// LevelHandler.
+	AdminAddr  string
+	AdminMux   *http.ServeMux
+	AdminToken string
 }
 type Logger struct {
 	log    *zap.Logger
+	sugar  *zap.SugaredLogger
 	config LoggerConfig
+	files  []*rotateWriter
+	node   *levelNode
+	drops  *dropCounters
+	async  []*asyncWriter
+	hooks  *hookSet
+}
+
+func newLogger(log *zap.Logger, config LoggerConfig) *Logger {
+	return &Logger{log: log, sugar: log.Sugar(), config: config}
+}
+
+// clone returns a copy of logger writing through log, sharing its outputs.
+func (logger *Logger) clone(log *zap.Logger) *Logger {
+	child := *logger
+	child.log = log
+	child.sugar = log.Sugar()
+	return &child
 }
 
+// NewLogInstance returns the logger of moduleName, see
+// FindOrCreateLoggerInstance. Empty paths default to files of the module.
 func NewLogInstance(moduleName, level, errorPath, logPath string) *Logger {
-	atomicLevel := zap.NewAtomicLevel()
-	atomicLevel.SetLevel(getLoggerLevel(level))
-	return initLogger(NewLoggerConfig(LoggerConfig{}), atomicLevel)
+	return FindOrCreateLoggerInstance(LoggerConfig{
+		ModuleName: moduleName,
+		Level:      level,
+		ErrorPath:  errorPath,
+		LogPath:    logPath,
+	})
 }
 
 func NewLoggerConfig(config LoggerConfig) LoggerConfig {
@@ -31,18 +140,32 @@ func NewLoggerConfig(config LoggerConfig) LoggerConfig {
 		config.Level = "debug"
 	}
 
+	// 模块日志默认写入 ./logs/<模块名>/ 目录
+	logDir := "./logs"
+	if config.ModuleName != "" {
+		logDir = filepath.Join(logDir, config.ModuleName)
+	}
+
 	if config.ErrorPath == "" {
-		config.ErrorPath = "./logs/error.log"
+		config.ErrorPath = filepath.Join(logDir, "error.log")
 	}
 
 	if config.LogPath == "" {
-		config.LogPath = "./logs/log.log"
+		config.LogPath = filepath.Join(logDir, "log.log")
 	}
 
 	if config.ModuleName != "" {
 		config.ModuleName += ": "
 	}
 
+	if config.MaxSize == 0 {
+		config.MaxSize = 16
+	}
+
+	if config.MaxAge == 0 {
+		config.MaxAge = 30
+	}
+
 	return config
 }
 
@@ -50,6 +173,27 @@ func (logger *Logger) Sync() {
 	logger.log.Sync()
 }
 
+// With returns a child logger that adds fields to every entry it writes.
+// The parent logger is not changed.
+func (logger *Logger) With(fields ...zap.Field) *Logger {
+	return logger.clone(logger.log.With(fields...))
+}
+
+// Named returns a child logger with name appended to the logger name, so
+// that Named("db").Named("conn") logs as "db.conn".
+// The child follows the level of its parent until a level of its own is set
+// through LevelHandler. Children are not registered, so name may vary, e.g.
+// per request, without growing the set of loggers.
+func (logger *Logger) Named(name string) *Logger {
+	if logger.node == nil {
+		return logger.clone(logger.log.Named(name))
+	}
+	node := logger.node.child(name)
+	child := logger.clone(withLevelNode(logger.log.Named(name), node))
+	child.node = node
+	return child
+}
+
 func (logger *Logger) CusError(err error, msg string, fields ...zap.Field) {
 	if err != nil {
 		logger.log.Error(logger.config.ModuleName+msg+err.Error(), fields...)
@@ -74,6 +218,85 @@ func (logger *Logger) Panic(msg string, fields ...zap.Field) {
 	logger.log.Panic(logger.config.ModuleName+msg, fields...)
 }
 
+func (logger *Logger) Warn(msg string, fields ...zap.Field) {
+	logger.log.Warn(logger.config.ModuleName+msg, fields...)
+}
+
+// DPanic logs at DPanicLevel, panicking afterwards only in development mode.
+func (logger *Logger) DPanic(msg string, fields ...zap.Field) {
+	logger.log.DPanic(logger.config.ModuleName+msg, fields...)
+}
+
+// Fatal logs at FatalLevel and then calls os.Exit(1).
+func (logger *Logger) Fatal(msg string, fields ...zap.Field) {
+	logger.log.Fatal(logger.config.ModuleName+msg, fields...)
+}
+
+// Debugf formats the message with fmt.Sprintf, Infof, Warnf... are the same.
+func (logger *Logger) Debugf(template string, args ...interface{}) {
+	logger.log.Debug(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) Infof(template string, args ...interface{}) {
+	logger.log.Info(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) Warnf(template string, args ...interface{}) {
+	logger.log.Warn(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) Errorf(template string, args ...interface{}) {
+	logger.log.Error(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) DPanicf(template string, args ...interface{}) {
+	logger.log.DPanic(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) Panicf(template string, args ...interface{}) {
+	logger.log.Panic(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+func (logger *Logger) Fatalf(template string, args ...interface{}) {
+	logger.log.Fatal(logger.config.ModuleName + fmt.Sprintf(template, args...))
+}
+
+// Debugw adds loosely typed key-value pairs as fields, as in
+// Debugw("query", "table", name, "rows", n). Infow, Warnw... are the same.
+func (logger *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Debugw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Infow(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Warnw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Errorw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) DPanicw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.DPanicw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Panicw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+func (logger *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	logger.sugar.Fatalw(logger.config.ModuleName+msg, keysAndValues...)
+}
+
+// Sugar returns the zap SugaredLogger behind logger, for the rare API the
+// wrapper does not cover.
+func (logger *Logger) Sugar() *zap.SugaredLogger {
+	return logger.sugar.WithOptions(zap.AddCallerSkip(-1))
+}
+
 func (logger *Logger) Binary(key string, val []byte) zap.Field {
 	return zap.Binary(key, val)
 }
@@ -152,46 +375,142 @@ func (logger *Logger) Uintptr(key string, val uintptr) zap.Field {
 
 func initLogger(config LoggerConfig, logLevel zap.AtomicLevel) *Logger {
 	// Error及以上日志
-	highw, _ := newHookLogger(config.ErrorPath, zap.ErrorLevel)
+	highw := newHookLogger(config.ErrorPath, config)
 	// 设置级别及以上日志
-	loww, _ := newHookLogger(config.LogPath, logLevel.Level())
+	loww := newHookLogger(config.LogPath, config)
+
+	drops := &dropCounters{}
+	var logw, errw zapcore.WriteSyncer = loww, highw
+	var async []*asyncWriter
+	if config.Async {
+		alog, aerr := newAsyncWriter(loww, config, drops), newAsyncWriter(highw, config, drops)
+		logw, errw = alog, aerr
+		async = []*asyncWriter{alog, aerr}
+	}
 
 	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-
-	// 控制台输出
-	consoleDebugging := zapcore.Lock(os.Stdout)
-	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
+	encoderConfig.EncodeTime = timeEncoder(config.TimeFormat)
 
-	core := zapcore.NewTee(
-		zapcore.NewCore(consoleEncoder, consoleDebugging, logLevel.Level()),
-		zapcore.NewCore(consoleEncoder, loww, logLevel.Level()),
-		zapcore.NewCore(consoleEncoder, highw, zap.ErrorLevel),
+	var cores []zapcore.Core
+	if !config.DisableStdout {
+		// 控制台输出
+		consoleDebugging := zapcore.Lock(os.Stdout)
+		cores = append(cores, zapcore.NewCore(newEncoder(config.StdoutEncoding, config.Encoding, encoderConfig), consoleDebugging, zap.DebugLevel))
+	}
+	cores = append(cores,
+		zapcore.NewCore(newEncoder(config.LogEncoding, config.Encoding, encoderConfig), logw, zap.DebugLevel),
+		zapcore.NewCore(newEncoder(config.ErrorEncoding, config.Encoding, encoderConfig), errw, zap.ErrorLevel),
 	)
 
-	logger := zap.New(core)
-	logger.Info(config.ModuleName + " logger init success")
-	defer logger.Sync()
+	var sinkErrs []error
+	for _, sink := range config.Sinks {
+		w, err := sink.open()
+		if err != nil {
+			sinkErrs = append(sinkErrs, err)
+			continue
+		}
+		var level zapcore.LevelEnabler = zap.DebugLevel
+		if sink.Level != "" {
+			level = getLoggerLevel(sink.Level)
+		}
+		cores = append(cores, zapcore.NewCore(newEncoder(sink.Encoding, config.Encoding, encoderConfig), w, level))
+	}
+
+	// the level of the logger is checked first by levelCore, then sampling
+	// and rate limiting, the outputs only filter by their own level
+	node := registerLevel(strings.TrimSuffix(config.ModuleName, ": "), logLevel)
+	l, redactErr := buildLogger(config, cores, node, drops)
+	for _, err := range sinkErrs {
+		l.log.Error(config.ModuleName+"logger sink disabled", zap.Error(err))
+	}
+	if redactErr != nil {
+		l.log.Error(config.ModuleName+"logger redaction incomplete", zap.Error(redactErr))
+	}
+	l.log.Info(config.ModuleName + " logger init success")
 
-	return &Logger{log: logger, config: config}
+	l.files = []*rotateWriter{loww, highw}
+	l.async = async
+	if config.ReopenOnSIGHUP {
+		reopenOnSIGHUP(l)
+	}
+	return l
+}
+
+// NewWithCore returns the logger of config writing to core instead of the
+// console and files, e.g. to keep the entries of tests in memory, see package
+// logtest. The level, caller, sampling, redaction and hooks of config apply.
+// The logger is not registered as a module logger.
+func NewWithCore(core zapcore.Core, config LoggerConfig) *Logger {
+	config = NewLoggerConfig(config)
+	name := strings.TrimSuffix(config.ModuleName, ": ")
+	if name == "" {
+		name = "default"
+	}
+	node := &levelNode{name: name, level: zap.NewAtomicLevelAt(getLoggerLevel(config.Level))}
+
+	l, err := buildLogger(config, []zapcore.Core{core}, node, &dropCounters{})
+	if err != nil {
+		l.log.Error(config.ModuleName+"logger redaction incomplete", zap.Error(err))
+	}
+	return l
 }
 
-func newHookLogger(logpath string, logLevel zapcore.Level) (zapcore.WriteSyncer, error) {
-	hook := lumberjack.Logger{
-		Filename:   logpath, // 日志文件路径
-		MaxSize:    16,      // megabytes
-		MaxBackups: 0,       // 最多保留3个备份
-		MaxAge:     30,      // days
-		Compress:   false,   // 是否压缩 disabled by default
-		LocalTime:  true,    // 是否使用服务器本地时间，默认UTC时间
+// buildLogger returns the logger of config writing to cores, at the level of
+// node, with the hooks, redaction, sampling and options of config.
+func buildLogger(config LoggerConfig, cores []zapcore.Core, node *levelNode, drops *dropCounters) (*Logger, error) {
+	hooks := &hookSet{}
+	cores = append(cores, &hookCore{hooks: hooks})
+
+	redactor, err := newRedactor(config)
+	if redactor != nil {
+		for i, c := range cores {
+			cores[i] = &redactCore{Core: c, redactor: redactor}
+		}
 	}
 
-	// priority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-	// 	return lvl >= logLevel
-	// })
+	var opts []zap.Option
+	if config.Caller {
+		// skip the frame of the Logger method
+		opts = append(opts, zap.AddCaller(), zap.AddCallerSkip(1))
+	}
+	if config.StacktraceLevel != "" {
+		opts = append(opts, zap.AddStacktrace(getLoggerLevel(config.StacktraceLevel)))
+	}
 
-	w := zapcore.AddSync(&hook)
-	return w, nil
+	core := newSampler(zapcore.NewTee(cores...), config, drops)
+	l := newLogger(zap.New(&levelCore{Core: core, node: node}, opts...), config)
+	l.node = node
+	l.drops = drops
+	l.hooks = hooks
+	return l, err
+}
+
+// newEncoder returns the encoder named by encoding, or by fallback if
+// encoding is empty.
+func newEncoder(encoding, fallback string, encoderConfig zapcore.EncoderConfig) zapcore.Encoder {
+	if encoding == "" {
+		encoding = fallback
+	}
+	if encoding == "json" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+func timeEncoder(format string) zapcore.TimeEncoder {
+	switch format {
+	case "", "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "epoch":
+		return zapcore.EpochTimeEncoder
+	case "millis":
+		return zapcore.EpochMillisTimeEncoder
+	case "nanos":
+		return zapcore.EpochNanosTimeEncoder
+	}
+	return zapcore.TimeEncoderOfLayout(format)
 }
 
 func getLoggerLevel(lvl string) zapcore.Level {
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelMethods(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{})
+	logger.config.ModuleName = "bill: "
+
+	logger.Warn("disk almost full")
+	logger.Infof("listening on :%d", 8080)
+	logger.Errorw("query failed", "table", "orders", "rows", 3)
+	func() {
+		defer func() { recover() }()
+		logger.Panicf("bad state %q", "x")
+	}()
+
+	want := []struct {
+		level zapcore.Level
+		msg   string
+	}{
+		{zapcore.WarnLevel, "bill: disk almost full"},
+		{zapcore.InfoLevel, "bill: listening on :8080"},
+		{zapcore.ErrorLevel, "bill: query failed"},
+		{zapcore.PanicLevel, `bill: bad state "x"`},
+	}
+	entries := logs.AllUntimed()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Level != w.level || entries[i].Message != w.msg {
+			t.Errorf("entry %d = %v %q, want %v %q", i, entries[i].Level, entries[i].Message, w.level, w.msg)
+		}
+	}
+	if fields := entries[2].ContextMap(); fields["table"] != "orders" || fields["rows"] != int64(3) {
+		t.Errorf("Errorw fields = %v", fields)
+	}
+}
@@ -1,34 +1,72 @@
 package logger
 
 import (
-	"go.uber.org/zap"
-	"net/http"
+	"reflect"
 	"sync"
+
+	"go.uber.org/zap"
 )
 
 var (
-	log  *Logger
-	once sync.Once
+	mu      sync.Mutex
+	log     *Logger // the first logger created, returned by FromContext by default
+	loggers = make(map[string]*Logger)
+	served  = make(map[interface{}]bool) // admin addresses and muxes in use
 )
 
+// FindOrCreateLoggerInstance returns the logger of config.ModuleName,
+// creating it from config the first time. Every module has its own files and
+// level; the config of a module that already has a logger is ignored.
 func FindOrCreateLoggerInstance(config LoggerConfig) *Logger {
-	once.Do(func() {
-		newConfig := NewLoggerConfig(config)
-		atomicLevel := waitSetLevel(newConfig.Level, newConfig.HasHTTPNet)
-		log = initLogger(newConfig, atomicLevel)
-	})
-	return log
-}
+	newConfig := NewLoggerConfig(config)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if l, ok := loggers[config.ModuleName]; ok {
+		if !reflect.DeepEqual(l.config, newConfig) {
+			l.Warn("logger already exists, new config ignored")
+		}
+		return l
+	}
+
+	l := initLogger(newConfig, zap.NewAtomicLevelAt(getLoggerLevel(newConfig.Level)))
+	loggers[config.ModuleName] = l
+	if log == nil {
+		log = l
+	}
 
-func waitSetLevel(level string, hasHTTP bool) zap.AtomicLevel {
-	alevel := zap.NewAtomicLevelAt(getLoggerLevel(level))
-	if hasHTTP {
-		http.HandleFunc("/log/level", alevel.ServeHTTP)
-		go func() {
-			if err := http.ListenAndServe(":9090", nil); err != nil {
-				panic(err)
-			}
-		}()
+	if newConfig.HasHTTPNet {
+		// one endpoint serves the levels of all loggers
+		var key interface{} = newConfig.AdminAddr
+		if newConfig.AdminMux != nil {
+			key = newConfig.AdminMux
+		}
+		if !served[key] {
+			served[key] = true
+			serveLevel(l, newConfig)
+		}
 	}
-	return alevel
+	return l
+}
+
+// Get returns the logger of the module name created by
+// FindOrCreateLoggerInstance or NewLogInstance.
+func Get(name string) (*Logger, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := loggers[name]
+	return l, ok
+}
+
+// Default returns the first logger created by FindOrCreateLoggerInstance or
+// NewLogInstance, false if the process has created none.
+func Default() (*Logger, bool) {
+	l := defaultLogger()
+	return l, l != nil
+}
+
+func defaultLogger() *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return log
 }
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	newConfig := func(module, level string) LoggerConfig {
+		return LoggerConfig{
+			ModuleName:    module,
+			Level:         level,
+			ErrorPath:     filepath.Join(dir, module, "error.log"),
+			LogPath:       filepath.Join(dir, module, "log.log"),
+			DisableStdout: true,
+		}
+	}
+
+	billing := FindOrCreateLoggerInstance(newConfig("regtest-billing", "info"))
+	orders := FindOrCreateLoggerInstance(newConfig("regtest-orders", "error"))
+	if billing == orders {
+		t.Fatal("modules share a logger")
+	}
+	if billing.files[0].hook.Filename == orders.files[0].hook.Filename {
+		t.Errorf("modules share the log file %s", billing.files[0].hook.Filename)
+	}
+	if billing.node.Level().String() != "info" || orders.node.Level().String() != "error" {
+		t.Errorf("levels = %s, %s", billing.node.Level(), orders.node.Level())
+	}
+
+	if l, ok := Get("regtest-billing"); !ok || l != billing {
+		t.Errorf("Get(regtest-billing) = %p, %v", l, ok)
+	}
+	if _, ok := Get("regtest-nothing"); ok {
+		t.Error("Get of an unknown module succeeded")
+	}
+	if l := FindOrCreateLoggerInstance(newConfig("regtest-billing", "debug")); l != billing {
+		t.Error("second FindOrCreateLoggerInstance created a new logger")
+	}
+}
+
+func TestNewLoggerConfigPaths(t *testing.T) {
+	config := NewLoggerConfig(LoggerConfig{ModuleName: "filedb"})
+	if config.LogPath != filepath.Join("logs", "filedb", "log.log") || config.ErrorPath != filepath.Join("logs", "filedb", "error.log") {
+		t.Errorf("paths = %s, %s", config.LogPath, config.ErrorPath)
+	}
+	config = NewLoggerConfig(LoggerConfig{})
+	if config.LogPath != filepath.Join("logs", "log.log") {
+		t.Errorf("default path = %s", config.LogPath)
+	}
+}
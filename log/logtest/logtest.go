@@ -0,0 +1,121 @@
+// Package logtest provides loggers that keep their entries in memory, so
+// that tests write no files under ./logs and can check what was logged.
+//
+//	log, logs := logtest.New(t)
+//	db := NewDB(log)
+//	db.Open()
+//	logs.Level(zap.ErrorLevel).AssertEmpty()
+//	logs.AssertLogged(zap.InfoLevel, "opened", zap.String("path", "test.db"))
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/fengfenghuo/go-common-lib/log"
+)
+
+// Logs is the entries of a test logger, or a filtered part of them.
+type Logs struct {
+	*observer.ObservedLogs
+	t testing.TB
+}
+
+// New returns a logger at debug level and its entries.
+func New(t testing.TB) (*logger.Logger, *Logs) {
+	return NewWithConfig(t, logger.LoggerConfig{})
+}
+
+// NewWithConfig returns a logger with the level, caller, sampling, redaction
+// and hooks of config, and its entries. The output settings of config are
+// ignored. Messages keep the prefix of config.ModuleName.
+func NewWithConfig(t testing.TB, config logger.LoggerConfig) (*logger.Logger, *Logs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return logger.NewWithCore(core, config), &Logs{ObservedLogs: logs, t: t}
+}
+
+func (l *Logs) filter(logs *observer.ObservedLogs) *Logs {
+	return &Logs{ObservedLogs: logs, t: l.t}
+}
+
+// Level returns the entries at level.
+func (l *Logs) Level(level zapcore.Level) *Logs {
+	return l.filter(l.FilterLevelExact(level))
+}
+
+// Message returns the entries with the message msg.
+func (l *Logs) Message(msg string) *Logs {
+	return l.filter(l.FilterMessage(msg))
+}
+
+// Contains returns the entries whose message contains s.
+func (l *Logs) Contains(s string) *Logs {
+	return l.filter(l.FilterMessageSnippet(s))
+}
+
+// Field returns the entries with the field f, among their own fields and
+// those of their logger.
+func (l *Logs) Field(f zapcore.Field) *Logs {
+	return l.filter(l.FilterField(f))
+}
+
+// FieldKey returns the entries with a field named key.
+func (l *Logs) FieldKey(key string) *Logs {
+	return l.filter(l.FilterFieldKey(key))
+}
+
+// AssertLen reports an error if there are not n entries.
+func (l *Logs) AssertLen(n int) {
+	l.t.Helper()
+	if got := l.Len(); got != n {
+		l.t.Errorf("logged %d entries, want %d%s", got, n, l.dump())
+	}
+}
+
+// AssertEmpty reports an error if there are entries.
+func (l *Logs) AssertEmpty() {
+	l.t.Helper()
+	l.AssertLen(0)
+}
+
+// AssertLogged reports an error unless an entry at level has the message msg
+// and all fields.
+func (l *Logs) AssertLogged(level zapcore.Level, msg string, fields ...zapcore.Field) {
+	l.t.Helper()
+	m := l.Level(level).Message(msg)
+	for _, f := range fields {
+		m = m.Field(f)
+	}
+	if m.Len() == 0 {
+		l.t.Errorf("no %s entry %q with fields %s%s", level, msg, fieldString(fields), l.dump())
+	}
+}
+
+// AssertNotLogged reports an error if an entry at level has the message msg.
+func (l *Logs) AssertNotLogged(level zapcore.Level, msg string) {
+	l.t.Helper()
+	if m := l.Level(level).Message(msg); m.Len() > 0 {
+		l.t.Errorf("unexpected %s entry %q%s", level, msg, m.dump())
+	}
+}
+
+// dump lists the entries for the error messages.
+func (l *Logs) dump() string {
+	var b strings.Builder
+	for _, e := range l.All() {
+		fmt.Fprintf(&b, "\n\t%s %q %v", e.Level, e.Message, e.ContextMap())
+	}
+	return b.String()
+}
+
+func fieldString(fields []zapcore.Field) string {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return fmt.Sprint(enc.Fields)
+}
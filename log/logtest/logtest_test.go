@@ -0,0 +1,63 @@
+package logtest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fengfenghuo/go-common-lib/log"
+	"github.com/fengfenghuo/go-common-lib/log/logtest"
+)
+
+// recorder records the errors reported by the assertions.
+type recorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	log, logs := logtest.New(t)
+	log.With(zap.String("db", "users")).Info("opened", zap.Int("handles", 16))
+	log.CusError(errors.New("disk full"), "write failed: ")
+	log.Debugw("cache miss", "key", "user:1")
+
+	logs.AssertLen(3)
+	logs.AssertLogged(zap.InfoLevel, "opened", zap.String("db", "users"), zap.Int("handles", 16))
+	logs.AssertLogged(zap.ErrorLevel, "write failed: disk full")
+	logs.AssertNotLogged(zap.WarnLevel, "opened")
+	logs.Level(zap.DebugLevel).Field(zap.String("key", "user:1")).AssertLen(1)
+	logs.Contains("failed").AssertLen(1)
+	logs.FieldKey("handles").Message("opened").AssertLen(1)
+	logs.Level(zap.WarnLevel).AssertEmpty()
+}
+
+func TestAssertionFailures(t *testing.T) {
+	r := &recorder{TB: t}
+	log, logs := logtest.New(r)
+	log.Info("opened", zap.Int("handles", 16))
+
+	logs.AssertLen(2)
+	logs.AssertEmpty()
+	logs.AssertLogged(zap.InfoLevel, "opened", zap.Int("handles", 8))
+	logs.AssertNotLogged(zap.InfoLevel, "opened")
+	if len(r.errs) != 4 {
+		t.Errorf("got %d errors, want 4: %q", len(r.errs), r.errs)
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	log, logs := logtest.NewWithConfig(t, logger.LoggerConfig{ModuleName: "db", Level: "warn", Redact: true})
+	log.Info("hidden")
+	log.Warn("slow", zap.String("password", "s3cret"))
+
+	logs.AssertLen(1)
+	logs.AssertLogged(zap.WarnLevel, "db: slow", zap.String("password", "******"))
+}
@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redactMask = "******"
+
+// DefaultRedactKeys are the field keys masked when Redact is set and
+// RedactKeys is empty.
+var DefaultRedactKeys = []string{"password", "token", "dsn", "authorization"}
+
+// DefaultRedactPatterns are the patterns masked when Redact is set and
+// RedactPatterns is empty: card numbers and emails.
+var DefaultRedactPatterns = []string{
+	`\b(?:\d[ -]?){12,18}\d\b`,
+	`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`,
+}
+
+// dsnPassword matches the password of a DSN or URL, user:password@host.
+var dsnPassword = regexp.MustCompile(`([\w.%-]+):[^@\s/:]+@`)
+
+// redactor masks secrets in messages and fields.
+type redactor struct {
+	keys     []string
+	keyValue *regexp.Regexp // key=value and key: value in text
+	patterns []*regexp.Regexp
+}
+
+// newRedactor returns the redactor of config, nil if redaction is off. The
+// invalid patterns are left out and returned as an error.
+func newRedactor(config LoggerConfig) (*redactor, error) {
+	if !config.Redact && len(config.RedactKeys) == 0 && len(config.RedactPatterns) == 0 {
+		return nil, nil
+	}
+	keys, patterns := config.RedactKeys, config.RedactPatterns
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultRedactPatterns
+	}
+
+	r := &redactor{}
+	quoted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		r.keys = append(r.keys, strings.ToLower(k))
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+	// the value may be an auth scheme followed by its credential, as in
+	// Authorization: Bearer abc.def
+	r.keyValue = regexp.MustCompile(`(?i)\b((?:\w*(?:` + strings.Join(quoted, "|") + `)\w*)\s*[=:]\s*)(?:(?:Bearer|Basic|Digest|Token)\s+)?[^\s,;&]+`)
+
+	var bad []string
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			bad = append(bad, err.Error())
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	if len(bad) > 0 {
+		return r, fmt.Errorf("invalid redact pattern: %s", strings.Join(bad, "; "))
+	}
+	return r, nil
+}
+
+// sensitive reports whether key contains one of the keys, ignoring case, so
+// that "password" also masks "db_password".
+func (r *redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// text masks the values of the keys, the passwords of DSNs and the patterns
+// found in s.
+func (r *redactor) text(s string) string {
+	s = r.keyValue.ReplaceAllString(s, "${1}"+redactMask)
+	s = dsnPassword.ReplaceAllString(s, "${1}:"+redactMask+"@")
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactMask)
+	}
+	return s
+}
+
+// fields returns fields with the sensitive keys masked, and the patterns
+// masked in string, error and Stringer values. fields is not modified.
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	var res []zapcore.Field
+	for i, f := range fields {
+		g, changed := r.field(f)
+		if changed && res == nil {
+			res = make([]zapcore.Field, len(fields))
+			copy(res, fields)
+		}
+		if res != nil {
+			res[i] = g
+		}
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, false
+	}
+	if r.sensitive(f.Key) {
+		return zap.String(f.Key, redactMask), true
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.text(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			if msg := err.Error(); r.text(msg) != msg {
+				return zap.String(f.Key, r.text(msg)), true
+			}
+		}
+	case zapcore.StringerType, zapcore.ReflectType:
+		// such as zap.Stringer("url", u) or zap.Any of a string type
+		if s, ok := stringValue(f.Interface); ok && r.text(s) != s {
+			return zap.String(f.Key, r.text(s)), true
+		}
+	}
+	return f, false
+}
+
+// stringValue returns the text of a fmt.Stringer or a value of a string
+// kind. It is false for other values and for a Stringer that panics, such as
+// a nil pointer, which the encoder reports itself.
+func stringValue(v interface{}) (s string, ok bool) {
+	switch val := v.(type) {
+	case fmt.Stringer:
+		defer func() {
+			if recover() != nil {
+				s, ok = "", false
+			}
+		}()
+		return val.String(), true
+	case nil:
+		return "", false
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+// redactCore masks the secrets of the entries of an output before they are
+// encoded.
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.text(ent.Message)
+	return c.Core.Write(ent, c.redactor.fields(fields))
+}
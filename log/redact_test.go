@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRedactFields(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{Redact: true})
+	logger.With(zap.String("db_password", "s3cret")).Info("login",
+		zap.String("Authorization", "Bearer abc"),
+		zap.String("user", "bob@example.com"),
+		zap.Int("token", 42),
+		zap.Error(errors.New("dial root:pw@tcp(db:3306)/app failed")),
+		zap.String("name", "bob"),
+	)
+
+	fields := logs.All()[0].ContextMap()
+	want := map[string]interface{}{
+		"db_password":   redactMask,
+		"Authorization": redactMask,
+		"user":          redactMask,
+		"token":         redactMask,
+		"error":         "dial root:" + redactMask + "@tcp(db:3306)/app failed",
+		"name":          "bob",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestRedactMessage(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{Redact: true})
+	logger.CusError(errors.New("open mysql://app:hunter2@db/app: refused"), "connect: ")
+	logger.Infof("paid with card 4111 1111 1111 1111, password=hunter2")
+
+	entries := logs.All()
+	if got, want := entries[0].Message, "connect: open mysql://app:"+redactMask+"@db/app: refused"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+	if got, want := entries[1].Message, "paid with card "+redactMask+", password="+redactMask; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+type dsnString string
+
+func TestRedactAuthAndStringers(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{Redact: true})
+	u, _ := url.Parse("mysql://root:pw@host/db")
+	logger.Info("request Authorization: Bearer abc.def.ghi done",
+		zap.String("header", "authorization=Basic dXNlcjpwdw=="),
+		zap.Stringer("url", u),
+		zap.Any("target", u),
+		zap.Any("conn", dsnString("root:pw@tcp(db)/app")),
+		zap.Stringer("nil", (*url.URL)(nil)),
+	)
+
+	e := logs.All()[0]
+	if want := "request Authorization: " + redactMask + " done"; e.Message != want {
+		t.Errorf("message = %q, want %q", e.Message, want)
+	}
+	fields := e.ContextMap()
+	want := map[string]interface{}{
+		"header": "authorization=" + redactMask,
+		"url":    "mysql://root:" + redactMask + "@host/db",
+		"target": "mysql://root:" + redactMask + "@host/db",
+		"conn":   "root:" + redactMask + "@tcp(db)/app",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	if r, _ := newRedactor(LoggerConfig{}); r != nil {
+		t.Error("redaction on without config")
+	}
+
+	logger, logs := newObservedLogger(LoggerConfig{RedactKeys: []string{"secret"}, RedactPatterns: []string{`id-\d+`}})
+	logger.Info("user id-42", zap.String("password", "kept"), zap.String("client_secret", "x"))
+	e := logs.All()[0]
+	if e.Message != "user "+redactMask {
+		t.Errorf("message = %q", e.Message)
+	}
+	if f := e.ContextMap(); f["password"] != "kept" || f["client_secret"] != redactMask {
+		t.Errorf("fields = %v", f)
+	}
+
+	if _, err := newRedactor(LoggerConfig{RedactPatterns: []string{"("}}); err == nil {
+		t.Error("no error for an invalid pattern")
+	}
+}
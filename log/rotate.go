@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// rotateWriter is a lumberjack.Logger that also starts a new file every
+// period when its file name is a pattern.
+type rotateWriter struct {
+	mu      sync.Mutex
+	hook    *lumberjack.Logger
+	pattern string // file name with %Y %m %d %H placeholders, empty without time rotation
+	utc     bool
+	now     func() time.Time
+}
+
+// newHookLogger returns the writer of a log file, rotated as set in config.
+func newHookLogger(logpath string, config LoggerConfig) *rotateWriter {
+	w := &rotateWriter{
+		hook: &lumberjack.Logger{
+			Filename:   logpath,           // 日志文件路径
+			MaxSize:    config.MaxSize,    // megabytes
+			MaxBackups: config.MaxBackups, // 最多保留的备份数, 0为全部保留
+			MaxAge:     config.MaxAge,     // days
+			Compress:   config.Compress,   // 是否压缩
+			LocalTime:  !config.UTCTime,   // 是否使用服务器本地时间，默认UTC时间
+		},
+		utc: config.UTCTime,
+		now: time.Now,
+	}
+	if config.MaxAge < 0 {
+		w.hook.MaxAge = 0
+	}
+	if config.RotateEvery != "" {
+		w.pattern = filePattern(logpath, config.RotateEvery)
+		w.hook.Filename = w.filename(w.now())
+	}
+	return w
+}
+
+// filePattern returns logpath if it already has placeholders, otherwise
+// logpath with the date (daily) or the date and hour (hourly) inserted before
+// the extension.
+func filePattern(logpath, every string) string {
+	if strings.Contains(logpath, "%") {
+		return logpath
+	}
+	suffix := ".%Y-%m-%d"
+	if every == "hourly" {
+		suffix += "-%H"
+	}
+	ext := filepath.Ext(logpath)
+	return strings.TrimSuffix(logpath, ext) + suffix + ext
+}
+
+func (w *rotateWriter) filename(t time.Time) string {
+	if w.utc {
+		t = t.UTC()
+	}
+	return strings.NewReplacer(
+		"%Y", fmt.Sprintf("%04d", t.Year()),
+		"%m", fmt.Sprintf("%02d", t.Month()),
+		"%d", fmt.Sprintf("%02d", t.Day()),
+		"%H", fmt.Sprintf("%02d", t.Hour()),
+		"%%", "%",
+	).Replace(w.pattern)
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pattern != "" {
+		if name := w.filename(w.now()); name != w.hook.Filename {
+			// a new lumberjack.Logger per period, its mill goroutine reads
+			// the settings of the old one
+			old := w.hook
+			old.Close()
+			w.hook = &lumberjack.Logger{
+				Filename:   name,
+				MaxSize:    old.MaxSize,
+				MaxBackups: old.MaxBackups,
+				MaxAge:     old.MaxAge,
+				Compress:   old.Compress,
+				LocalTime:  old.LocalTime,
+			}
+			w.prune()
+		}
+	}
+	return w.hook.Write(p)
+}
+
+// prune removes the files of the previous periods, and their backups, beyond
+// MaxBackups or older than MaxAge days. lumberjack only removes the backups
+// of the current file.
+func (w *rotateWriter) prune() {
+	if w.hook.MaxBackups == 0 && w.hook.MaxAge == 0 {
+		return
+	}
+	dir := filepath.Dir(w.pattern)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	re := periodFiles(filepath.Base(w.pattern))
+	current := filepath.Base(w.hook.Filename)
+
+	type file struct {
+		path string
+		mod  time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == current || !re.MatchString(e.Name()) {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, file{filepath.Join(dir, e.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	cutoff := w.now().Add(-time.Duration(w.hook.MaxAge) * 24 * time.Hour)
+	for i, f := range files {
+		if (w.hook.MaxBackups > 0 && i >= w.hook.MaxBackups) || (w.hook.MaxAge > 0 && f.mod.Before(cutoff)) {
+			os.Remove(f.path)
+		}
+	}
+}
+
+// periodFiles returns the regexp matching the files of pattern, a base name
+// with placeholders, and their lumberjack backups, compressed or not.
+func periodFiles(pattern string) *regexp.Regexp {
+	ext := filepath.Ext(pattern)
+	if strings.Contains(ext, "%") {
+		ext = ""
+	}
+	name := regexp.QuoteMeta(strings.TrimSuffix(pattern, ext))
+	name = strings.NewReplacer(`%Y`, `\d{4}`, `%m`, `\d{2}`, `%d`, `\d{2}`, `%H`, `\d{2}`, `%%`, `%`).Replace(name)
+	backup := `(-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3})?`
+	return regexp.MustCompile(`^` + name + backup + regexp.QuoteMeta(ext) + `(\.gz)?$`)
+}
+
+// Sync is a no-op, lumberjack writes straight to the file.
+func (w *rotateWriter) Sync() error {
+	return nil
+}
+
+// Rotate moves the current file to a backup and starts a new one.
+func (w *rotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.hook.Rotate()
+}
+
+// Reopen closes the current file; the next write opens the file name again,
+// so a file moved away by logrotate is released.
+func (w *rotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.hook.Close()
+}
+
+// Rotate moves the log and error files to backups and starts new ones.
+func (logger *Logger) Rotate() error {
+	var errs []string
+	for _, f := range logger.files {
+		if err := f.Rotate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("logger: rotate: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Reopen closes the log and error files, which are opened again by the next
+// write. It is what a SIGHUP does when ReopenOnSIGHUP is set.
+func (logger *Logger) Reopen() error {
+	var errs []string
+	for _, f := range logger.files {
+		if err := f.Reopen(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("logger: reopen: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// reopenOnSIGHUP reopens the files of logger every time the process gets a
+// SIGHUP, the signal logrotate sends after moving the files.
+func reopenOnSIGHUP(logger *Logger) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			if err := logger.Reopen(); err != nil {
+				logger.Error("reopen log files failed", zap.Error(err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestFilePattern(t *testing.T) {
+	tests := []struct{ path, every, want string }{
+		{"./logs/log.log", "daily", "./logs/log.%Y-%m-%d.log"},
+		{"./logs/log.log", "hourly", "./logs/log.%Y-%m-%d-%H.log"},
+		{"./logs/app-%Y%m%d.log", "daily", "./logs/app-%Y%m%d.log"},
+	}
+	for _, tt := range tests {
+		if got := filePattern(tt.path, tt.every); got != tt.want {
+			t.Errorf("filePattern(%q, %q) = %q, want %q", tt.path, tt.every, got, tt.want)
+		}
+	}
+
+	w := newHookLogger("app-%Y%m%d-%H.log", LoggerConfig{RotateEvery: "hourly", UTCTime: true})
+	at := time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC)
+	if got := w.filename(at); got != "app-20240305-07.log" {
+		t.Errorf("filename = %q", got)
+	}
+}
+
+func TestRotateAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		DisableStdout: true,
+		MaxBackups:    2,
+	}), zap.NewAtomicLevelAt(zap.DebugLevel))
+	if got := logger.files[0].hook.MaxSize; got != 16 {
+		t.Errorf("MaxSize = %d, want default 16", got)
+	}
+
+	if err := logger.Rotate(); err != nil {
+		t.Fatalf("Rotate error: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "log-*.log"))
+	if len(matches) != 1 {
+		t.Errorf("rotated files = %v, want 1 backup", matches)
+	}
+
+	// logrotate moves the file away, then sends SIGHUP
+	moved := filepath.Join(dir, "moved.log")
+	if err := os.Rename(filepath.Join(dir, "log.log"), moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Reopen(); err != nil {
+		t.Fatalf("Reopen error: %v", err)
+	}
+	logger.Info("after reopen")
+	if _, err := os.Stat(filepath.Join(dir, "log.log")); err != nil {
+		t.Errorf("log file not recreated: %v", err)
+	}
+}
+
+func TestRotateEveryPrunes(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	w := newHookLogger(filepath.Join(dir, "log.log"), LoggerConfig{RotateEvery: "daily", UTCTime: true, MaxBackups: 2})
+	w.now = func() time.Time { return day }
+	w.hook.Filename = w.filename(day)
+
+	// files of earlier days, and one unrelated file
+	for i := 1; i <= 4; i++ {
+		name := w.filename(day.AddDate(0, 0, -i))
+		if err := os.WriteFile(name, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(name, day.AddDate(0, 0, -i), day.AddDate(0, 0, -i))
+	}
+	other := filepath.Join(dir, "other.log")
+	os.WriteFile(other, nil, 0644)
+
+	w.Write([]byte("today\n"))
+	day = day.AddDate(0, 0, 1)
+	w.Write([]byte("tomorrow\n"))
+	defer w.Reopen()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "log.*.log"))
+	// the current file and MaxBackups files of earlier days
+	if len(matches) != 3 {
+		t.Errorf("files = %v, want 3", matches)
+	}
+	for _, keep := range []string{"log.2024-03-11.log", "log.2024-03-10.log", "log.2024-03-09.log"} {
+		if _, err := os.Stat(filepath.Join(dir, keep)); err != nil {
+			t.Errorf("%s removed", keep)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("unrelated file removed")
+	}
+}
+
+func TestPeriodFiles(t *testing.T) {
+	re := periodFiles("log.%Y-%m-%d-%H.log")
+	for name, want := range map[string]bool{
+		"log.2024-03-05-07.log":                            true,
+		"log.2024-03-05-07-2024-03-05T07-10-00.000.log":    true,
+		"log.2024-03-05-07-2024-03-05T07-10-00.000.log.gz": true,
+		"log.log":                   false,
+		"error.2024-03-05-07.log":   false,
+		"log.2024-03-05-07.log.bak": false,
+	} {
+		if got := re.MatchString(name); got != want {
+			t.Errorf("match %q = %v, want %v", name, got, want)
+		}
+	}
+}
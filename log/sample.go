@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultRateLimitInterval = time.Minute
+	// rateLimitSweep is the number of keys above which expired windows are
+	// removed, so that the limiter does not grow with unique messages.
+	rateLimitSweep = 1024
+)
+
+// DropCounts counts the entries a logger did not write.
+type DropCounts struct {
+	Sampled     uint64 // dropped by sampling
+	RateLimited uint64 // dropped by the rate limiter
+	Buffer      uint64 // dropped because the Async buffer was full
+}
+
+type dropCounters struct {
+	sampled     atomic.Uint64
+	rateLimited atomic.Uint64
+	buffer      atomic.Uint64
+}
+
+// Dropped returns the number of entries dropped since the logger was
+// created, for all its children.
+func (logger *Logger) Dropped() DropCounts {
+	if logger.drops == nil {
+		return DropCounts{}
+	}
+	return DropCounts{
+		Sampled:     logger.drops.sampled.Load(),
+		RateLimited: logger.drops.rateLimited.Load(),
+		Buffer:      logger.drops.buffer.Load(),
+	}
+}
+
+// newSampler wraps core with the sampling and the rate limiting of config.
+func newSampler(core zapcore.Core, config LoggerConfig, drops *dropCounters) zapcore.Core {
+	if config.SamplingFirst > 0 {
+		tick := config.SamplingTick
+		if tick <= 0 {
+			tick = time.Second
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, config.SamplingFirst, config.SamplingThereafter,
+			zapcore.SamplerHook(func(_ zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped != 0 {
+					drops.sampled.Add(1)
+				}
+			}))
+	}
+
+	if config.RateLimit > 0 {
+		interval := config.RateLimitInterval
+		if interval <= 0 {
+			interval = defaultRateLimitInterval
+		}
+		level := zapcore.ErrorLevel
+		if config.RateLimitLevel != "" {
+			level = getLoggerLevel(config.RateLimitLevel)
+		}
+		core = &rateLimitCore{Core: core, limiter: &rateLimiter{
+			limit:    config.RateLimit,
+			interval: interval,
+			level:    level,
+			windows:  make(map[rateKey]*rateWindow),
+			drops:    drops,
+		}}
+	}
+	return core
+}
+
+type rateKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter allows limit entries per key in every window of interval.
+type rateLimiter struct {
+	limit    int
+	interval time.Duration
+	level    zapcore.Level
+	drops    *dropCounters
+
+	mu      sync.Mutex
+	windows map[rateKey]*rateWindow
+}
+
+func (r *rateLimiter) allow(ent zapcore.Entry) bool {
+	if ent.Level < r.level {
+		return true
+	}
+	key := rateKey{level: ent.Level, logger: ent.LoggerName, message: ent.Message}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.windows[key]
+	if !ok || ent.Time.Sub(w.start) >= r.interval {
+		if !ok && len(r.windows) >= rateLimitSweep {
+			r.sweep(ent.Time)
+		}
+		r.windows[key] = &rateWindow{start: ent.Time, count: 1}
+		return true
+	}
+	if w.count < r.limit {
+		w.count++
+		return true
+	}
+	r.drops.rateLimited.Add(1)
+	return false
+}
+
+func (r *rateLimiter) sweep(now time.Time) {
+	for key, w := range r.windows {
+		if now.Sub(w.start) >= r.interval {
+			delete(r.windows, key)
+		}
+	}
+}
+
+// rateLimitCore drops the entries its rateLimiter does not allow.
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *rateLimiter
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) || !c.limiter.allow(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampling(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{SamplingFirst: 2, SamplingThereafter: 3, SamplingTick: time.Hour})
+	for i := 0; i < 10; i++ {
+		logger.Info("tick")
+	}
+	logger.Info("other")
+
+	// 1, 2, then 5 and 8
+	if n := logs.FilterMessage("tick").Len(); n != 4 {
+		t.Errorf("got %d sampled entries, want 4", n)
+	}
+	if logs.FilterMessage("other").Len() != 1 {
+		t.Error("entry with another message was sampled out")
+	}
+	if d := logger.Dropped(); d.Sampled != 6 || d.RateLimited != 0 {
+		t.Errorf("Dropped() = %+v", d)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{RateLimit: 2, RateLimitInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		logger.Error("connect failed")
+		logger.Named("db").Error("connect failed")
+		logger.Info("retry")
+	}
+
+	if n := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); n != 4 {
+		t.Errorf("got %d errors, want 2 per logger", n)
+	}
+	if n := logs.FilterMessage("retry").Len(); n != 5 {
+		t.Errorf("got %d info entries, want all 5", n)
+	}
+	if d := logger.Named("db").Dropped(); d.RateLimited != 6 {
+		t.Errorf("Dropped() = %+v", d)
+	}
+}
+
+func TestRateLimitWindow(t *testing.T) {
+	r := &rateLimiter{limit: 1, interval: time.Minute, level: zapcore.WarnLevel,
+		windows: make(map[rateKey]*rateWindow), drops: &dropCounters{}}
+	now := time.Now()
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Message: "slow", Time: now}
+	if !r.allow(ent) {
+		t.Fatal("first entry dropped")
+	}
+	if r.allow(ent) {
+		t.Error("second entry in the window allowed")
+	}
+	ent.Time = now.Add(time.Minute)
+	if !r.allow(ent) {
+		t.Error("entry of the next window dropped")
+	}
+}
+
+func TestCoreChain(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{Redact: true, RateLimit: 1, RateLimitInterval: time.Hour})
+	var hooked []Entry
+	logger.AddHook(zapcore.ErrorLevel, func(e Entry) { hooked = append(hooked, e) })
+
+	for i := 0; i < 3; i++ {
+		logger.Error("connect failed", zap.String("dsn", "root:pw@tcp(db)/app"))
+	}
+
+	// rate limiting applies before both the outputs and the hooks, the
+	// redaction to both
+	if logs.Len() != 1 || len(hooked) != 1 {
+		t.Fatalf("got %d entries and %d hooked, want 1 each", logs.Len(), len(hooked))
+	}
+	if v := logs.All()[0].ContextMap()["dsn"]; v != redactMask {
+		t.Errorf("output dsn = %v", v)
+	}
+	if v := hooked[0].Fields["dsn"]; v != redactMask {
+		t.Errorf("hooked dsn = %v", v)
+	}
+	if d := logger.Dropped(); d.RateLimited != 2 {
+		t.Errorf("Dropped() = %+v", d)
+	}
+}
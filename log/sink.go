@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Sink is an extra log output.
+type Sink struct {
+	// Type is one of stdout, stderr, syslog and writer.
+	Type string
+	// Encoding overrides LoggerConfig.Encoding for this sink.
+	Encoding string
+	// Level is the minimum level written to the sink, by default the level
+	// of the logger.
+	Level string
+	// Writer receives the entries of a writer sink.
+	Writer io.Writer
+	// Address is the unix socket of the syslog daemon, found automatically
+	// if empty. Tag is the syslog tag, by default the program name.
+	Address string
+	Tag     string
+}
+
+func (sink Sink) open() (zapcore.WriteSyncer, error) {
+	switch sink.Type {
+	case "stdout":
+		return zapcore.Lock(os.Stdout), nil
+	case "stderr":
+		return zapcore.Lock(os.Stderr), nil
+	case "syslog":
+		w, err := dialSyslog(sink.Address, sink.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("syslog sink: %s", err.Error())
+		}
+		return zapcore.AddSync(w), nil
+	case "writer":
+		if sink.Writer == nil {
+			return nil, fmt.Errorf("writer sink: Writer is nil")
+		}
+		return zapcore.Lock(zapcore.AddSync(sink.Writer)), nil
+	}
+	return nil, fmt.Errorf("unknown sink type %q", sink.Type)
+}
@@ -0,0 +1,15 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/syslog"
+)
+
+func dialSyslog(address, tag string) (io.Writer, error) {
+	if address == "" {
+		return syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	}
+	return syslog.Dial("unixgram", address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+}
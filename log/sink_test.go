@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSinksAndEncoding(t *testing.T) {
+	dir := t.TempDir()
+	var jsonOut, consoleOut bytes.Buffer
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		LogEncoding:   "json",
+		DisableStdout: true,
+		TimeFormat:    "2006-01-02",
+		Sinks: []Sink{
+			{Type: "writer", Writer: &jsonOut, Encoding: "json", Level: "warn"},
+			{Type: "writer", Writer: &consoleOut},
+			{Type: "bogus"},
+		},
+	}), zap.NewAtomicLevelAt(zap.DebugLevel))
+
+	logger.Warn("disk almost full", logger.Int("percent", 91))
+	logger.Sync()
+
+	lines := strings.Split(strings.TrimSpace(jsonOut.String()), "\n")
+	// the disabled bogus sink is reported at error level
+	if len(lines) != 2 {
+		t.Fatalf("json sink got %d lines:\n%s", len(lines), jsonOut.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("json sink line is not json: %v", err)
+	}
+	if entry["msg"] != "disk almost full" || entry["percent"] != 91.0 || len(entry["ts"].(string)) != len("2006-01-02") {
+		t.Errorf("json entry = %v", entry)
+	}
+	if !strings.Contains(consoleOut.String(), "logger init success") || !strings.Contains(consoleOut.String(), "\tdisk almost full\t") {
+		t.Errorf("console sink:\n%s", consoleOut.String())
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "log.log"))
+	if err != nil || !strings.Contains(string(b), `"msg":"disk almost full"`) {
+		t.Errorf("log file is not json: %s %v", b, err)
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Handler returns logger as a log/slog Handler. Attributes become fields,
+// and groups prefix the keys of their attributes, as in "req.method".
+//
+//	slog.SetDefault(slog.New(logger.Handler()))
+func (logger *Logger) Handler() slog.Handler {
+	return &slogHandler{logger: logger}
+}
+
+type slogHandler struct {
+	logger *Logger
+	prefix string // keys prefix of the open groups, "a.b."
+}
+
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case l >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case l >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return h.logger.log.Core().Enabled(zapLevel(l))
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	ce := h.logger.log.Check(zapLevel(r.Level), h.logger.config.ModuleName+r.Message)
+	if ce == nil {
+		return nil
+	}
+	if !r.Time.IsZero() {
+		ce.Time = r.Time
+	}
+	// slog knows the caller, the frames of zap would give the handler
+	if ce.Caller.Defined && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ce.Caller = zapcore.EntryCaller{Defined: true, PC: r.PC, File: frame.File, Line: frame.Line, Function: frame.Function}
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{logger: h.logger.With(fields...), prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// appendAttr appends a as fields, the attributes of a group with their keys
+// prefixed.
+func appendAttr(fields []zap.Field, prefix string, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	key := prefix + a.Key
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			prefix = key + "."
+		}
+		for _, g := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, g)
+		}
+		return fields
+	case slog.KindString:
+		return append(fields, zap.String(key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(key, a.Value.Time()))
+	default:
+		return append(fields, zap.Any(key, a.Value.Any()))
+	}
+}
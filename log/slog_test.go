@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := newLogger(zap.New(core), LoggerConfig{ModuleName: "api: "})
+	sl := slog.New(logger.Handler())
+
+	sl.Debug("hidden")
+	sl.With("trace_id", "abc").WithGroup("req").Warn("slow request",
+		"method", "GET",
+		slog.Duration("took", 2*time.Second),
+		slog.Group("user", "id", 42),
+		"err", errors.New("timeout"),
+	)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zapcore.WarnLevel || e.Message != "api: slow request" {
+		t.Errorf("entry = %+v", e.Entry)
+	}
+	want := map[string]interface{}{
+		"trace_id":    "abc",
+		"req.method":  "GET",
+		"req.took":    2 * time.Second,
+		"req.user.id": int64(42),
+		"req.err":     "timeout",
+	}
+	fields := e.ContextMap()
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestSlogLevels(t *testing.T) {
+	core, _ := observer.New(zapcore.WarnLevel)
+	h := newLogger(zap.New(core), LoggerConfig{}).Handler()
+	for l, want := range map[slog.Level]bool{
+		slog.LevelDebug: false,
+		slog.LevelInfo:  false,
+		slog.LevelWarn:  true,
+		slog.LevelError: true,
+		slog.Level(12):  true,
+	} {
+		if got := h.Enabled(context.Background(), l); got != want {
+			t.Errorf("Enabled(%v) = %v, want %v", l, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	stdlog "log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Writer returns an io.Writer that writes everything written to it as an
+// entry of logger at level, for log.SetOutput or the ErrorLog of an
+// http.Server.
+func (logger *Logger) Writer(level zapcore.Level) io.Writer {
+	// skip the frames of log.Logger, so that the caller is its caller
+	return &lineWriter{logger: logger, log: logger.log.WithOptions(zap.AddCallerSkip(2)), level: level}
+}
+
+type lineWriter struct {
+	logger *Logger
+	log    *zap.Logger
+	level  zapcore.Level
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	if ce := w.log.Check(w.level, w.logger.config.ModuleName+msg); ce != nil {
+		ce.Write()
+	}
+	return len(p), nil
+}
+
+// StdLogger returns a standard library logger writing to logger at level. It
+// also satisfies the Logger interface of the paho MQTT client:
+//
+//	mqtt.ERROR = logger.StdLogger(zap.ErrorLevel)
+func (logger *Logger) StdLogger(level zapcore.Level) *stdlog.Logger {
+	return stdlog.New(logger.Writer(level), "", 0)
+}
+
+// RedirectStdLog sends the output of the standard log package to logger at
+// level, until restore is called.
+func (logger *Logger) RedirectStdLog(level zapcore.Level) (restore func()) {
+	flags, prefix, out := stdlog.Flags(), stdlog.Prefix(), stdlog.Writer()
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(logger.Writer(level))
+	return func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetOutput(out)
+	}
+}
+
+// GormLogger writes the logs of jinzhu/gorm to a Logger, SQL statements at
+// debug level and errors at error level:
+//
+//	db.SetLogger(logger.GormLogger())
+type GormLogger struct {
+	logger *Logger
+}
+
+// GormLogger returns logger as a logger of jinzhu/gorm.
+func (logger *Logger) GormLogger() GormLogger {
+	return GormLogger{logger: logger}
+}
+
+// Print implements the logger interface of gorm, which passes the kind of
+// the log, "sql", "log" or "error", its source and its values.
+func (g GormLogger) Print(values ...interface{}) {
+	if len(values) < 2 {
+		g.logger.Info(sprintln(values))
+		return
+	}
+	source := zap.Any("source", values[1])
+	switch values[0] {
+	case "sql":
+		if len(values) >= 6 {
+			g.logger.Debug("sql", source,
+				zap.Any("duration", values[2]),
+				zap.Any("sql", values[3]),
+				zap.Any("vars", values[4]),
+				zap.Any("rows", values[5]))
+			return
+		}
+	case "error":
+		g.logger.Error(sprintln(values[2:]), source)
+		return
+	}
+	g.logger.Info(sprintln(values[2:]), source)
+}
+
+// sprintln formats values the way gorm prints them, separated by spaces.
+func sprintln(values []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(values...), "\n")
+}
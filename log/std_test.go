@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"errors"
+	stdlog "log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStdLogger(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{})
+	logger.StdLogger(zapcore.WarnLevel).Printf("disk %d%% full", 90)
+
+	restore := logger.RedirectStdLog(zapcore.InfoLevel)
+	stdlog.Println("from std log")
+	restore()
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Level != zapcore.WarnLevel || entries[0].Message != "disk 90% full" {
+		t.Errorf("entry = %+v", entries[0].Entry)
+	}
+	if entries[1].Level != zapcore.InfoLevel || entries[1].Message != "from std log" {
+		t.Errorf("entry = %+v", entries[1].Entry)
+	}
+}
+
+func TestStdLoggerCaller(t *testing.T) {
+	dir := t.TempDir()
+	logger := initLogger(NewLoggerConfig(LoggerConfig{
+		ErrorPath:     filepath.Join(dir, "error.log"),
+		LogPath:       filepath.Join(dir, "log.log"),
+		DisableStdout: true,
+		Caller:        true,
+	}), zap.NewAtomicLevelAt(zap.DebugLevel))
+	var caller zapcore.EntryCaller
+	logger.AddHook(zapcore.InfoLevel, func(e Entry) { caller = e.Caller })
+
+	logger.StdLogger(zapcore.InfoLevel).Print("here")
+	if filepath.Base(caller.File) != "std_test.go" {
+		t.Errorf("caller = %v", caller)
+	}
+}
+
+func TestGormLogger(t *testing.T) {
+	logger, logs := newObservedLogger(LoggerConfig{})
+	gorm := logger.GormLogger()
+	gorm.Print("sql", "/app/user.go:12", 3*time.Millisecond, "SELECT * FROM users WHERE id = ?", []interface{}{7}, int64(1))
+	gorm.Print("error", "/app/user.go:20", errors.New("record not found"))
+	gorm.Print("log", "/app/user.go:30", "slow", "query")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	sql := entries[0].ContextMap()
+	if entries[0].Level != zapcore.DebugLevel || sql["sql"] != "SELECT * FROM users WHERE id = ?" ||
+		sql["rows"] != int64(1) || sql["duration"] != 3*time.Millisecond {
+		t.Errorf("sql entry = %+v %v", entries[0].Entry, sql)
+	}
+	wants := []observer.LoggedEntry{
+		{Entry: zapcore.Entry{Level: zapcore.ErrorLevel, Message: "record not found"}},
+		{Entry: zapcore.Entry{Level: zapcore.InfoLevel, Message: "slow query"}},
+	}
+	for i, want := range wants {
+		if e := entries[i+1]; e.Level != want.Level || e.Message != want.Message {
+			t.Errorf("entry %d = %+v, want %+v", i+1, e.Entry, want.Entry)
+		}
+	}
+}